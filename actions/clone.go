@@ -1,14 +1,12 @@
 package actions
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"sort"
-	"strings"
 
 	"github.com/brasa-ai/yankrun/domain"
 	"github.com/brasa-ai/yankrun/helpers"
+	"github.com/brasa-ai/yankrun/prompt"
 	"github.com/brasa-ai/yankrun/services"
 
 	"github.com/urfave/cli"
@@ -34,13 +32,22 @@ func (a *CloneAction) Execute(c *cli.Context) error {
 	repoURL := c.String("repo")
 	outputDir := c.String("outputDir")
 	verbose := c.Bool("verbose")
-	input := c.String("input")
+	inputFiles := c.StringSlice("input")
 	fileSizeLimit := c.String("fileSizeLimit")
 	startDelim := c.String("startDelim")
 	endDelim := c.String("endDelim")
 	interactive := c.Bool("interactive")
 	processTemplates := c.Bool("processTemplates")
 	onlyTemplates := c.Bool("onlyTemplates")
+	engine := c.String("engine")
+	suffixFlags := c.StringSlice("templateSuffix")
+	templateInfix := c.Bool("templateInfix")
+	ignorePatterns := c.StringSlice("ignore")
+	sets := c.StringSlice("set")
+	setFiles := c.StringSlice("set-file")
+	explainKeys := c.StringSlice("explain")
+	check := c.Bool("check")
+	dryRun := c.Bool("dry-run") || check
 
 	// Load defaults from config when flags not provided
 	cfg, _ := services.Load()
@@ -70,8 +77,20 @@ func (a *CloneAction) Execute(c *cli.Context) error {
 	if onlyTemplates && !processTemplates {
 		return fmt.Errorf("--onlyTemplates requires --processTemplates to be set")
 	}
+	if err := checkEngine(engine, processTemplates); err != nil {
+		return err
+	}
 
-	if err := a.fs.EnsureDir(outputDir); err != nil {
+	// In dry-run mode clone into a temporary directory and keep every write in memory
+	replacer, overlay := dryRunReplacer(a.fs, a.replacer, dryRun)
+	if dryRun {
+		tmpDir, cleanup, err := dryRunCloneDir()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		outputDir = tmpDir
+	} else if err := a.fs.EnsureDir(outputDir); err != nil {
 		return err
 	}
 
@@ -81,65 +100,75 @@ func (a *CloneAction) Execute(c *cli.Context) error {
 
 	helpers.Log.Info().Msgf("Cloned into %s", outputDir)
 
-	// Parse provided replacements if any
-	var provided domain.InputReplacement
-	if input != "" {
-		var err error
-		provided, err = a.parser.Parse(input)
-		if err != nil {
-			return err
-		}
+	// Parse provided replacements if any, merging the values files in order
+	provided, sources, err := a.parser.ParseAll(inputFiles)
+	if err != nil {
+		return err
+	}
+
+	// --ignore flags extend the ignore_patterns from the values file. The partials of the
+	// template are only rendered through includes, they are removed from the output
+	provided.IgnorePath = append(provided.IgnorePath, append(ignorePatterns, services.PartialsIgnorePattern)...)
+
+	// YANKRUN_VAR_* environment variables, --set-file and --set override the values file
+	if err := applyOverrides(a.fs, &provided, sources, os.Environ(), setFiles, sets); err != nil {
+		return err
+	}
+
+	// Template engine, template suffixes and per-file delimiters from the flags, the
+	// config and the values file
+	suffixes := templateSuffixes(suffixFlags, templateInfix, cfg, repoURL)
+	replacer, err = configureReplacer(replacer, cfg, provided, engine, suffixes)
+	if err != nil {
+		return err
+	}
+
+	// Variable declarations and conditional paths from the yankrun.yaml of the template
+	manifest, err := loadManifest(replacer, outputDir, &provided)
+	if err != nil {
+		return err
 	}
 
 	// Analyze placeholders in cloned directory
-	counts, err := a.replacer.AnalyzeDir(outputDir, fileSizeLimit, startDelim, endDelim, onlyTemplates)
+	placeholders, err := replacer.AnalyzePlaceholders(outputDir, provided.IgnorePath, fileSizeLimit, startDelim, endDelim, onlyTemplates)
 	if err != nil {
 		return err
 	}
+	if err := addPathConditionRefs(placeholders, provided); err != nil {
+		return err
+	}
 
 	// Build value map from provided input
-	values := map[string]string{}
-	for _, r := range provided.Variables {
-		values[r.Key] = r.Value
+	inputs := newRunInputs(provided, manifest, placeholders, sources, startDelim, endDelim)
+	if err := inputs.addDerivedRefs(placeholders); err != nil {
+		return err
+	}
+	addAskIfRefs(manifest, placeholders)
+	warnManifestMismatch(manifest, placeholders, inputs)
+	if err := inputs.applyManifest(manifest, placeholders); err != nil {
+		return err
 	}
 
 	// If interactive, prompt for each discovered key
-	final := domain.InputReplacement{}
-	if len(counts) > 0 {
-		keys := make([]string, 0, len(counts))
-		for k := range counts {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-
-		helpers.Log.Info().Msg("Discovered placeholders:")
-		for _, k := range keys {
-			v := values[k]
-			if v == "" {
-				v = "(unset)"
-			}
-			fmt.Printf("  %-24s  matches=%-6d  value=%s\n", k, counts[k], v)
-		}
+	var final domain.InputReplacement
+	if len(placeholders) > 0 {
+		keys := inputs.orderKeys(sortedKeys(placeholders))
+		printSummary(keys, placeholders, inputs)
 
 		if interactive {
-			r := bufio.NewReader(os.Stdin)
-			for _, k := range keys {
-				def := values[k]
-				fmt.Printf("Enter value for %s [%s]: ", k, def)
-				s, _ := r.ReadString('\n')
-				s = strings.TrimSpace(s)
-				if s != "" {
-					values[k] = s
-				}
+			if err := promptInputs(prompt.New(os.Stdin, os.Stdout), keys, placeholders, inputs); err != nil {
+				return err
 			}
-			fmt.Println()
 		}
 
-		for _, k := range keys {
-			if v, ok := values[k]; ok && v != "" {
-				final.Variables = append(final.Variables, domain.Replacement{Key: k, Value: v})
-			}
+		if err := inputs.resolveDerived(replacer); err != nil {
+			return err
 		}
+		if err := inputs.checkRequired(keys, placeholders); err != nil {
+			return err
+		}
+		final = inputs.final(keys, provided)
+		inputs.explain(explainKeys, placeholders)
 	} else {
 		// No discovered keys; use provided values directly
 		final = provided
@@ -147,19 +176,23 @@ func (a *CloneAction) Execute(c *cli.Context) error {
 
 	// Skip regular templating if onlyTemplates is set
 	if !onlyTemplates {
-		if err := a.replacer.ReplaceInDir(outputDir, final, fileSizeLimit, startDelim, endDelim, verbose); err != nil {
+		if err := replacer.ReplaceInDir(outputDir, withoutTemplates(final, processTemplates, suffixes), fileSizeLimit, startDelim, endDelim, verbose); err != nil {
 			return err
 		}
 	}
 
-	// Process .tpl files if requested
+	// Process template files if requested
 	if processTemplates {
-		if err := a.replacer.ProcessTemplateFiles(outputDir, final, fileSizeLimit, startDelim, endDelim, verbose); err != nil {
+		if err := replacer.ProcessTemplateFiles(outputDir, final, fileSizeLimit, startDelim, endDelim, verbose); err != nil {
 			return err
 		}
 		helpers.Log.Info().Msg("Template file processing complete ✔")
 	}
 
+	if err := finishOutput(replacer, overlay, manifest, outputDir, verbose, check); err != nil || overlay != nil {
+		return err
+	}
+
 	helpers.Log.Info().Msg("Templating complete ✔")
 
 	return nil
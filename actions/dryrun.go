@@ -0,0 +1,104 @@
+package actions
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/brasa-ai/yankrun/helpers"
+	"github.com/brasa-ai/yankrun/services"
+)
+
+// dryRunReplacer wraps replacer so that every change lands in an in-memory overlay
+// instead of on disk. The overlay is nil when dryRun is false.
+func dryRunReplacer(fs services.FileSystem, replacer services.Replacer, dryRun bool) (services.Replacer, *services.OverlayFileSystem) {
+	if !dryRun {
+		return replacer, nil
+	}
+	overlay := services.NewOverlayFileSystem(fs)
+	return replacer.WithFileSystem(overlay), overlay
+}
+
+// finishDryRun prints a summary and a unified diff of every planned change below root.
+// With check set it fails when anything would change, so CI can gate on it. It does
+// nothing when overlay is nil, outside of dry-run mode.
+func finishDryRun(overlay *services.OverlayFileSystem, root string, check bool) error {
+	if overlay == nil {
+		return nil
+	}
+	changes, err := overlay.Changes()
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		helpers.Log.Info().Msg("Dry run: no changes")
+		return nil
+	}
+
+	rel := func(p string) string {
+		if r, err := filepath.Rel(root, p); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+
+	helpers.Log.Info().Msgf("Dry run: %d file(s) would change:", len(changes))
+	for _, ch := range changes {
+		if ch.Kind == services.ChangeRenamed {
+			fmt.Printf("  %s %s -> %s\n", ch.Kind, rel(ch.OldPath), rel(ch.Path))
+		} else {
+			fmt.Printf("  %s %s\n", ch.Kind, rel(ch.Path))
+		}
+	}
+	fmt.Println()
+
+	for _, ch := range changes {
+		oldName, newName := "a/"+rel(ch.Path), "b/"+rel(ch.Path)
+		switch ch.Kind {
+		case services.ChangeAdded:
+			oldName = "/dev/null"
+		case services.ChangeRemoved:
+			// removals are listed in the summary only
+			continue
+		case services.ChangeRenamed:
+			oldName = "a/" + rel(ch.OldPath)
+		}
+		if ch.ModeChanged() {
+			fmt.Printf("old mode %s\nnew mode %s\n", gitMode(ch.OldMode), gitMode(ch.NewMode))
+		}
+		if ch.Kind == services.ChangeRenamed {
+			fmt.Printf("rename from %s\nrename to %s\n", rel(ch.OldPath), rel(ch.Path))
+		}
+		if bytes.Equal(ch.Before, ch.After) {
+			// a mode change or a pure rename
+			continue
+		}
+		if ch.IsBinary() {
+			fmt.Printf("Binary files %s and %s differ\n", oldName, newName)
+			continue
+		}
+		fmt.Print(services.UnifiedDiff(oldName, newName, string(ch.Before), string(ch.After)))
+	}
+
+	if check {
+		return fmt.Errorf("dry run: %d file(s) would change", len(changes))
+	}
+	return nil
+}
+
+// gitMode formats the permission bits of a regular file the way git does, as in 100755.
+func gitMode(perm fs.FileMode) string {
+	return fmt.Sprintf("%06o", 0100000|perm.Perm())
+}
+
+// dryRunCloneDir creates a temporary directory to clone into during a dry run
+// and returns a cleanup function that removes it.
+func dryRunCloneDir() (string, func(), error) {
+	dir, err := os.MkdirTemp("", "yankrun-dry-run-")
+	if err != nil {
+		return "", nil, err
+	}
+	return dir, func() { _ = os.RemoveAll(dir) }, nil
+}
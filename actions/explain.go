@@ -0,0 +1,53 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/brasa-ai/yankrun/helpers"
+	"github.com/brasa-ai/yankrun/services"
+)
+
+// explain prints, for each key given to --explain, every source that set it, in the
+// order they were applied, and marks the one whose value is used.
+func (in *runInputs) explain(keys []string, infos map[string]*services.PlaceholderInfo) {
+	for _, k := range keys {
+		helpers.Log.Info().Msgf("Explain %s:", k)
+		info := infos[k]
+		if info == nil {
+			fmt.Println("  not used by any file")
+		}
+
+		// defaults apply first, every other source overrides them
+		var steps []services.ValueSource
+		spec, declared := in.specs[k]
+		switch {
+		case declared && spec.HasDefault():
+			steps = append(steps, services.ValueSource{Source: services.ManifestFile + " default", Value: defaultText(spec.Default)})
+		case info != nil && info.HasDefault:
+			steps = append(steps, services.ValueSource{Source: "inline default", Value: info.Default})
+		}
+		steps = append(steps, in.sources[k]...)
+		if len(steps) == 0 {
+			fmt.Println("  not set")
+			continue
+		}
+
+		for i, step := range steps {
+			value := step.Value
+			if spec.Secret {
+				value = "****"
+			}
+			used := ""
+			if i == len(steps)-1 {
+				used = "  <- used"
+			}
+			fmt.Printf("  %-32s  %s%s\n", step.Source, value, used)
+		}
+		if _, ok := in.derived[k]; ok {
+			fmt.Printf("  %-32s  %s\n", "resolved", in.values[k])
+		}
+		if reason := in.skipReason(k); reason != "" {
+			fmt.Printf("  question skipped (ask_if: %s)\n", reason)
+		}
+	}
+}
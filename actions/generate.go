@@ -1,16 +1,15 @@
 package actions
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 
 	"github.com/brasa-ai/yankrun/domain"
 	"github.com/brasa-ai/yankrun/helpers"
+	"github.com/brasa-ai/yankrun/prompt"
 	"github.com/brasa-ai/yankrun/services"
 	"github.com/urfave/cli"
 )
@@ -30,7 +29,7 @@ func NewGenerateAction(fs services.FileSystem, cloner services.Cloner, parser se
 func (a *GenerateAction) Execute(c *cli.Context) error {
 	// parse flags first for non-interactive allowance
 	interactivePrompt := c.Bool("interactive")
-	input := c.String("input")
+	inputFiles := c.StringSlice("input")
 	startDelim := c.String("startDelim")
 	endDelim := c.String("endDelim")
 	fileSizeLimit := c.String("fileSizeLimit")
@@ -40,11 +39,26 @@ func (a *GenerateAction) Execute(c *cli.Context) error {
 	branchFlag := c.String("branch")
 	processTemplates := c.Bool("processTemplates")
 	onlyTemplates := c.Bool("onlyTemplates")
+	engine := c.String("engine")
+	suffixFlags := c.StringSlice("templateSuffix")
+	templateInfix := c.Bool("templateInfix")
+	ignorePatterns := c.StringSlice("ignore")
+	sets := c.StringSlice("set")
+	setFiles := c.StringSlice("set-file")
+	explainKeys := c.StringSlice("explain")
+	check := c.Bool("check")
+	dryRun := c.Bool("dry-run") || check
 
 	// Validate flag combination
 	if onlyTemplates && !processTemplates {
 		return fmt.Errorf("--onlyTemplates requires --processTemplates to be set")
 	}
+	if err := checkEngine(engine, processTemplates); err != nil {
+		return err
+	}
+
+	// One prompter for the whole run, so no answer is lost to a second buffer on stdin
+	p := prompt.New(os.Stdin, os.Stdout)
 
 	cfg, err := services.Load()
 	if err != nil {
@@ -52,10 +66,9 @@ func (a *GenerateAction) Execute(c *cli.Context) error {
 	}
 	if len(cfg.Templates) == 0 && cfg.GitHub.User == "" && len(cfg.GitHub.Orgs) == 0 && templateFilter == "" {
 		// Ask minimal discovery setup inline
-		r := bufio.NewReader(os.Stdin)
 		fmt.Println("No templates configured. Let's set where to search:")
-		u := strings.TrimSpace(promptString(r, "GitHub user (optional, Enter to skip)", ""))
-		orgsCSV := strings.TrimSpace(promptString(r, "GitHub orgs (comma-separated, optional)", ""))
+		u := ask(p, prompt.Question{Label: "GitHub user (optional, Enter to skip)"})
+		orgsCSV := ask(p, prompt.Question{Label: "GitHub orgs (comma-separated, optional)"})
 		var orgs []string
 		if orgsCSV != "" {
 			for _, p := range strings.Split(orgsCSV, ",") {
@@ -91,8 +104,6 @@ func (a *GenerateAction) Execute(c *cli.Context) error {
 		fileSizeLimit = "3 mb"
 	}
 
-	r := bufio.NewReader(os.Stdin)
-
 	// Aggregate configured repos + discovered GitHub repos
 	repos := cfg.Templates
 	// Allow direct URL via --template for non-interactive shortcut
@@ -135,9 +146,7 @@ func (a *GenerateAction) Execute(c *cli.Context) error {
 		for i, t := range repos {
 			fmt.Printf("  [%d] %s  (%s)\n", i+1, t.Name, t.URL)
 		}
-		fmt.Printf("Filter templates by substring (press Enter to skip): ")
-		filter, _ := r.ReadString('\n')
-		filter = strings.TrimSpace(filter)
+		filter := ask(p, prompt.Question{Label: "Filter templates by substring (press Enter to skip)"})
 		filtered = nil
 		if filter == "" {
 			filtered = repos
@@ -151,19 +160,13 @@ func (a *GenerateAction) Execute(c *cli.Context) error {
 				filtered = repos
 			}
 		}
+		options := make([]string, len(filtered))
 		for i, t := range filtered {
-			fmt.Printf("  [%d] %s  (%s)\n", i+1, t.Name, t.URL)
-		}
-		fmt.Printf("Select template [1-%d]: ", len(filtered))
-		selStr, _ := r.ReadString('\n')
-		selStr = strings.TrimSpace(selStr)
-		idx := 0
-		if selStr != "" {
-			fmt.Sscanf(selStr, "%d", &idx)
-			idx--
+			options[i] = fmt.Sprintf("%s  (%s)", t.Name, t.URL)
 		}
-		if idx < 0 || idx >= len(filtered) {
-			idx = 0
+		idx, err := p.Select("Select template", options, 0)
+		if err != nil {
+			return err
 		}
 		chosen = filtered[idx]
 	}
@@ -184,9 +187,7 @@ func (a *GenerateAction) Execute(c *cli.Context) error {
 			br = "main"
 		}
 	} else {
-		fmt.Printf("Type to filter branches (Enter to accept default [%s]): ", chosen.DefaultBranch)
-		branchFilter, _ := r.ReadString('\n')
-		branchFilter = strings.TrimSpace(branchFilter)
+		branchFilter := ask(p, prompt.Question{Label: fmt.Sprintf("Type to filter branches (Enter to accept default [%s])", chosen.DefaultBranch)})
 		var candidates []string
 		if branchFilter == "" {
 			candidates = branches
@@ -201,29 +202,27 @@ func (a *GenerateAction) Execute(c *cli.Context) error {
 			}
 		}
 		fmt.Println("Available branches:")
-		for i, b := range candidates {
-			fmt.Printf("  [%d] %s\n", i+1, b)
+		idx, err := p.Select("Select branch (Enter for default)", candidates, -1)
+		if err != nil {
+			return err
 		}
-		fmt.Printf("Select branch [1-%d] (Enter for default): ", len(candidates))
-		pick, _ := r.ReadString('\n')
-		pick = strings.TrimSpace(pick)
 		br = chosen.DefaultBranch
-		if pick != "" {
-			var idx int
-			if _, err := fmt.Sscanf(pick, "%d", &idx); err == nil && idx >= 1 && idx <= len(candidates) {
-				br = candidates[idx-1]
-			}
+		if idx >= 0 {
+			br = candidates[idx]
 		}
 	}
 
-	if outputDir == "" {
-		fmt.Printf("Output directory [./new-project]: ")
-		out, _ := r.ReadString('\n')
-		out = strings.TrimSpace(out)
-		if out == "" {
-			out = "./new-project"
+	// In dry-run mode clone into a temporary directory and keep every write in memory
+	replacer, overlay := dryRunReplacer(a.fs, a.replacer, dryRun)
+	if dryRun {
+		tmpDir, cleanup, err := dryRunCloneDir()
+		if err != nil {
+			return err
 		}
-		outputDir = out
+		defer cleanup()
+		outputDir = tmpDir
+	} else if outputDir == "" {
+		outputDir = ask(p, prompt.Question{Label: "Output directory", Default: "./new-project"})
 	}
 
 	if err := a.fs.EnsureDir(outputDir); err != nil {
@@ -242,103 +241,104 @@ func (a *GenerateAction) Execute(c *cli.Context) error {
 	}
 	helpers.Log.Info().Msg("Removed .git directory (new repo initialized)")
 
-	// Parse provided values if any
-	var provided domain.InputReplacement
-	if input != "" {
-		provided, err = a.parser.Parse(input)
-		if err != nil {
-			return err
-		}
+	// Parse provided values if any, merging the values files in order
+	provided, sources, err := a.parser.ParseAll(inputFiles)
+	if err != nil {
+		return err
+	}
+
+	// --ignore flags extend the ignore_patterns from the values file. The partials of the
+	// template are only rendered through includes, they are removed from the output
+	provided.IgnorePath = append(provided.IgnorePath, append(ignorePatterns, services.PartialsIgnorePattern)...)
+
+	// YANKRUN_VAR_* environment variables, --set-file and --set override the values file
+	if err := applyOverrides(a.fs, &provided, sources, os.Environ(), setFiles, sets); err != nil {
+		return err
+	}
+
+	// Template engine, template suffixes and per-file delimiters from the flags, the
+	// config and the values file
+	suffixes := templateSuffixes(suffixFlags, templateInfix, cfg, chosen.URL)
+	replacer, err = configureReplacer(replacer, cfg, provided, engine, suffixes)
+	if err != nil {
+		return err
+	}
+
+	// Variable declarations and conditional paths from the yankrun.yaml of the template
+	manifest, err := loadManifest(replacer, outputDir, &provided)
+	if err != nil {
+		return err
 	}
 
 	// Analyze placeholders
-	counts, err := a.replacer.AnalyzeDir(outputDir, fileSizeLimit, startDelim, endDelim, onlyTemplates)
+	placeholders, err := replacer.AnalyzePlaceholders(outputDir, provided.IgnorePath, fileSizeLimit, startDelim, endDelim, onlyTemplates)
 	if err != nil {
 		return err
 	}
-	if len(counts) == 0 {
+	if err := addPathConditionRefs(placeholders, provided); err != nil {
+		return err
+	}
+	if len(placeholders) == 0 {
 		helpers.Log.Info().Msg("No placeholders found.")
-		return nil
+		return finishOutput(replacer, overlay, manifest, outputDir, verbose, check)
 	}
 
 	// Build values map
-	values := map[string]string{}
-	for _, rpl := range provided.Variables {
-		values[rpl.Key] = rpl.Value
+	inputs := newRunInputs(provided, manifest, placeholders, sources, startDelim, endDelim)
+	if err := inputs.addDerivedRefs(placeholders); err != nil {
+		return err
+	}
+	addAskIfRefs(manifest, placeholders)
+	warnManifestMismatch(manifest, placeholders, inputs)
+	if err := inputs.applyManifest(manifest, placeholders); err != nil {
+		return err
 	}
 
 	// Show summary
-	keys := make([]string, 0, len(counts))
-	for k := range counts {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	helpers.Log.Info().Msg("Discovered placeholders:")
-	for _, k := range keys {
-		v := values[k]
-		if v == "" {
-			v = "(unset)"
-		}
-		fmt.Printf("  %-24s  matches=%-6d  value=%s\n", k, counts[k], v)
-	}
+	keys := inputs.orderKeys(sortedKeys(placeholders))
+	printSummary(keys, placeholders, inputs)
 
 	// Prompt if requested
 	if interactivePrompt {
-		for _, k := range keys {
-			def := values[k]
-			fmt.Printf("Enter value for %s [%s]: ", k, def)
-			s, _ := r.ReadString('\n')
-			s = strings.TrimSpace(s)
-			if s != "" {
-				values[k] = s
-			}
+		if err := promptInputs(p, keys, placeholders, inputs); err != nil {
+			return err
 		}
-		fmt.Println()
 	}
 
 	// Build final replacements
-	final := domain.InputReplacement{}
-	for _, k := range keys {
-		if v, ok := values[k]; ok && v != "" {
-			final.Variables = append(final.Variables, domain.Replacement{Key: k, Value: v})
-		}
+	if err := inputs.resolveDerived(replacer); err != nil {
+		return err
+	}
+	if err := inputs.checkRequired(keys, placeholders); err != nil {
+		return err
 	}
+	final := inputs.final(keys, provided)
+	inputs.explain(explainKeys, placeholders)
 
-	if len(final.Variables) == 0 {
+	if nothingToApply(final, placeholders) {
 		helpers.Log.Info().Msg("No values provided; nothing to replace.")
-		return nil
+		return finishOutput(replacer, overlay, manifest, outputDir, verbose, check)
 	}
 
 	// Skip regular templating if onlyTemplates is set
 	if !onlyTemplates {
-		if err := a.replacer.ReplaceInDir(outputDir, final, fileSizeLimit, startDelim, endDelim, verbose); err != nil {
+		if err := replacer.ReplaceInDir(outputDir, withoutTemplates(final, processTemplates, suffixes), fileSizeLimit, startDelim, endDelim, verbose); err != nil {
 			return err
 		}
 	}
 
-	// Process .tpl files if requested
+	// Process template files if requested
 	if processTemplates {
-		if err := a.replacer.ProcessTemplateFiles(outputDir, final, fileSizeLimit, startDelim, endDelim, verbose); err != nil {
+		if err := replacer.ProcessTemplateFiles(outputDir, final, fileSizeLimit, startDelim, endDelim, verbose); err != nil {
 			return err
 		}
 		helpers.Log.Info().Msg("Template file processing complete ✔")
 	}
 
+	if err := finishOutput(replacer, overlay, manifest, outputDir, verbose, check); err != nil || overlay != nil {
+		return err
+	}
+
 	helpers.Log.Info().Msg("Templating complete ✔")
 	return nil
 }
-
-// promptString reads a line with a label and returns the trimmed value or default when empty
-func promptString(r *bufio.Reader, label string, def string) string {
-	if def == "" {
-		fmt.Printf("%s: ", label)
-	} else {
-		fmt.Printf("%s [%s]: ", label, def)
-	}
-	if v, err := r.ReadString('\n'); err == nil {
-		if s := strings.TrimSpace(v); s != "" {
-			return s
-		}
-	}
-	return def
-}
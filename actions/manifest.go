@@ -0,0 +1,220 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brasa-ai/yankrun/domain"
+	"github.com/brasa-ai/yankrun/helpers"
+	"github.com/brasa-ai/yankrun/prompt"
+	"github.com/brasa-ai/yankrun/services"
+)
+
+// loadManifest reads the yankrun.yaml manifest of the template in dir, if any, leaves
+// it out of templating and puts its conditional paths before those of the values file.
+func loadManifest(replacer services.Replacer, dir string, in *domain.InputReplacement) (*domain.Manifest, error) {
+	m, err := replacer.LoadManifest(dir)
+	if err != nil || m == nil {
+		return m, err
+	}
+	in.IgnorePath = append(in.IgnorePath, services.ManifestIgnorePattern)
+	in.PathConditions = append(append([]domain.PathCondition{}, m.PathConditions...), in.PathConditions...)
+	return m, nil
+}
+
+// addAskIfRefs adds the declared variables that the ask_if conditions of the discovered
+// ones depend on, so they are asked for even when no file uses them.
+func addAskIfRefs(m *domain.Manifest, infos map[string]*services.PlaceholderInfo) {
+	if m == nil {
+		return
+	}
+	// conditions only refer to earlier variables, so one pass from the end finds them all
+	for i := len(m.Variables) - 1; i >= 0; i-- {
+		spec := m.Variables[i]
+		if infos[spec.Name] == nil {
+			continue
+		}
+		conds := []string{spec.AskIf}
+		if spec.Section != nil {
+			conds = append(conds, spec.Section.AskIf)
+		}
+		for _, cond := range conds {
+			refs, _ := services.ConditionRefs(cond)
+			for _, ref := range refs {
+				if infos[ref] == nil {
+					infos[ref] = &services.PlaceholderInfo{}
+				}
+			}
+		}
+	}
+}
+
+// warnManifestMismatch warns about variables the manifest declares but no file uses,
+// and about placeholders the manifest does not declare.
+func warnManifestMismatch(m *domain.Manifest, infos map[string]*services.PlaceholderInfo, in *runInputs) {
+	if m == nil {
+		return
+	}
+	declared := map[string]bool{}
+	for _, v := range m.Variables {
+		declared[v.Name] = true
+		if infos[v.Name] == nil {
+			helpers.Log.Warn().Msgf("%s declares %s, but no file uses it", services.ManifestFile, v.Name)
+		}
+	}
+	for _, k := range sortedKeys(infos) {
+		if _, derived := in.derived[k]; !declared[k] && !derived {
+			helpers.Log.Warn().Msgf("%s is used but not declared in %s", k, services.ManifestFile)
+		}
+	}
+}
+
+// applyManifest records the declarations of the discovered variables, adds their
+// defaults and required markers to infos and checks the values from the values file.
+func (in *runInputs) applyManifest(m *domain.Manifest, infos map[string]*services.PlaceholderInfo) error {
+	if m == nil {
+		return nil
+	}
+	var problems []string
+	for _, spec := range m.Variables {
+		info := infos[spec.Name]
+		if info == nil {
+			continue
+		}
+		in.specs[spec.Name] = spec
+		in.order = append(in.order, spec.Name)
+		if spec.HasDefault() {
+			info.Default, info.HasDefault = defaultText(spec.Default), true
+			if spec.Secret {
+				info.Default = "****"
+			}
+		}
+		if spec.Required {
+			info.Required = true
+			info.RequiredAt = append(info.RequiredAt, services.ManifestFile)
+		}
+		if err := in.checkValue(spec); err != nil {
+			problems = append(problems, fmt.Sprintf("  %s: %v", spec.Name, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid values:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// checkValue checks the current value of a declared variable and stores it in
+// canonical form.
+func (in *runInputs) checkValue(spec domain.VariableSpec) error {
+	if list, ok := in.lists[spec.Name]; ok {
+		return services.CheckList(spec, list)
+	}
+	v, err := services.CheckValue(spec, in.values[spec.Name])
+	if err != nil {
+		return err
+	}
+	if v != "" {
+		in.values[spec.Name] = v
+	}
+	return nil
+}
+
+// orderKeys puts the declared variables first, in manifest order, then the others.
+func (in *runInputs) orderKeys(keys []string) []string {
+	ordered := append([]string{}, in.order...)
+	for _, k := range keys {
+		if _, ok := in.specs[k]; !ok {
+			ordered = append(ordered, k)
+		}
+	}
+	return ordered
+}
+
+// lookup resolves k in ask_if conditions: its current value, or its default.
+func (in *runInputs) lookup(k string) (string, bool) {
+	if v := in.values[k]; v != "" {
+		return v, true
+	}
+	if l, ok := in.lists[k]; ok {
+		return defaultText(domain.Replacement{List: l}), true
+	}
+	if spec := in.specs[k]; spec.HasDefault() {
+		return defaultText(spec.Default), true
+	}
+	return "", false
+}
+
+// skipReason returns the ask_if condition that is false for k, or "" when k is asked for.
+func (in *runInputs) skipReason(k string) string {
+	spec, ok := in.specs[k]
+	if !ok {
+		return ""
+	}
+	return services.SkipQuestion(spec, in.lookup)
+}
+
+// sectionHeading prints the title and description of a section, before its first
+// question or summary line.
+func sectionHeading(s *domain.Section, indent string) {
+	fmt.Printf("%s-- %s --\n", indent, s.Title)
+	if s.Description != "" {
+		fmt.Printf("%s%s\n", indent, s.Description)
+	}
+}
+
+// question builds the prompt for k, shaped by its declaration in the manifest.
+func (in *runInputs) question(k string, info *services.PlaceholderInfo) prompt.Question {
+	q := prompt.Question{Label: "Enter value for " + k, Default: in.promptDefault(k, info)}
+	spec, declared := in.specs[k]
+	if !declared {
+		return q
+	}
+	q.Help, q.Hint = spec.Description, promptHint(spec)
+	q.Secret, q.Multiline = spec.Secret, spec.Multiline
+	switch spec.Type {
+	case domain.TypeBool:
+		q.YesNo = true
+	case domain.TypeEnum:
+		q.Choices = spec.Choices
+	}
+	q.Validate = func(v string) (string, error) { return services.CheckValue(spec, v) }
+	return q
+}
+
+// promptHint describes the expected answer for a declared int or list; enums and
+// booleans are shown as a menu and as y/n by the prompt itself.
+func promptHint(spec domain.VariableSpec) string {
+	switch spec.Type {
+	case domain.TypeInt:
+		switch {
+		case spec.Min != nil && spec.Max != nil:
+			return fmt.Sprintf("integer %d-%d", *spec.Min, *spec.Max)
+		case spec.Min != nil:
+			return fmt.Sprintf("integer, at least %d", *spec.Min)
+		case spec.Max != nil:
+			return fmt.Sprintf("integer, at most %d", *spec.Max)
+		}
+		return "integer"
+	case domain.TypeList:
+		if len(spec.Choices) > 0 {
+			return "comma-separated: " + strings.Join(spec.Choices, ", ")
+		}
+		return "comma-separated"
+	}
+	return ""
+}
+
+// defaultText formats a manifest default for the summary and the prompt.
+func defaultText(r domain.Replacement) string {
+	if r.List == nil {
+		return r.Value
+	}
+	var items []string
+	for _, item := range r.List {
+		if item.Fields != nil {
+			return fmt.Sprintf("[%d items]", len(r.List))
+		}
+		items = append(items, item.Value)
+	}
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,63 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brasa-ai/yankrun/domain"
+	"github.com/brasa-ai/yankrun/services"
+)
+
+// envVarPrefix marks environment variables that set template variables:
+// YANKRUN_VAR_APP_NAME=demo sets APP_NAME.
+const envVarPrefix = "YANKRUN_VAR_"
+
+// applyOverrides sets variables from the environment, --set-file and --set on top of
+// those of the values file. A value from a later source replaces the earlier one:
+//
+//	manifest default < values file < environment < --set-file < --set < interactive answer
+//
+// --set-file reads the value from a file, without its final line break.
+func applyOverrides(fs services.FileSystem, in *domain.InputReplacement, sources services.Sources, environ, setFiles, sets []string) error {
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(key, envVarPrefix) && key != envVarPrefix {
+			setVariable(in, sources, key, strings.TrimPrefix(key, envVarPrefix), value)
+		}
+	}
+	for _, kv := range setFiles {
+		key, path, err := splitAssignment("--set-file", kv)
+		if err != nil {
+			return err
+		}
+		data, err := fs.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("--set-file %s: %w", key, err)
+		}
+		value := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+		setVariable(in, sources, "--set-file "+path, key, value)
+	}
+	for _, kv := range sets {
+		key, value, err := splitAssignment("--set", kv)
+		if err != nil {
+			return err
+		}
+		setVariable(in, sources, "--set", key, value)
+	}
+	return nil
+}
+
+func splitAssignment(flag, kv string) (string, string, error) {
+	key, value, ok := strings.Cut(kv, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("%s %q: expected KEY=VALUE", flag, kv)
+	}
+	return key, value, nil
+}
+
+// setVariable replaces the value of key, or adds it, and records where it came from.
+func setVariable(in *domain.InputReplacement, sources services.Sources, source, key, value string) {
+	r := domain.Replacement{Key: key, Value: value}
+	sources.Add(key, source, r)
+	*in = services.MergeInputs(*in, domain.InputReplacement{Variables: []domain.Replacement{r}})
+}
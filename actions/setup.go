@@ -1,7 +1,6 @@
 package actions
 
 import (
-    "bufio"
     "errors"
     "flag"
     "fmt"
@@ -10,19 +9,10 @@ import (
 
     "github.com/brasa-ai/yankrun/domain"
     "github.com/brasa-ai/yankrun/helpers"
+    "github.com/brasa-ai/yankrun/prompt"
     "github.com/brasa-ai/yankrun/services"
 )
 
-func prompt(r *bufio.Reader, label, def string) string {
-    fmt.Printf("%s [%s]: ", label, def)
-    if v, err := r.ReadString('\n'); err == nil {
-        if v = strings.TrimSpace(v); v != "" {
-            return v
-        }
-    }
-    return def
-}
-
 // RunSetup configures defaults (~/.yankrun/config.yaml). If --show is present, prints current config and exits.
 func RunSetup(args []string) error {
     // support --show flag even when invoked from cli.Command Action context
@@ -49,6 +39,9 @@ func RunSetup(args []string) error {
         // Display current config (pretty)
         helpers.Log.Info().Msg("Current configuration:")
         fmt.Printf("\n  start_delim:     %q\n  end_delim:       %q\n  file_size_limit: %s\n", cfg.StartDelim, cfg.EndDelim, cfg.FileSizeLimit)
+        if len(cfg.TemplateSuffixes) > 0 || cfg.TemplateInfix {
+            fmt.Printf("  template_suffixes: %s (infix=%t)\n", strings.Join(cfg.TemplateSuffixes, ", "), cfg.TemplateInfix)
+        }
         // Show templates
         fmt.Printf("  templates:       %d configured\n", len(cfg.Templates))
         for i, t := range cfg.Templates {
@@ -66,7 +59,7 @@ func RunSetup(args []string) error {
         return nil
     }
 
-    r := bufio.NewReader(os.Stdin)
+    p := prompt.New(os.Stdin, os.Stdout)
     // Defaults if empty
     if cfg.StartDelim == "" { cfg.StartDelim = "[[" }
     if cfg.EndDelim == "" { cfg.EndDelim = "]]" }
@@ -75,58 +68,38 @@ func RunSetup(args []string) error {
     }
 
     // Clear, unambiguous prompts for delimiters and size
-    fmt.Printf("Template start delimiter (current: %q, e.g., [[]): ", cfg.StartDelim)
-    if v, err := r.ReadString('\n'); err == nil {
-        if s := strings.TrimSpace(v); s != "" { cfg.StartDelim = s }
-    }
-    fmt.Printf("Template end delimiter (current: %q, e.g., ]]): ", cfg.EndDelim)
-    if v, err := r.ReadString('\n'); err == nil {
-        if s := strings.TrimSpace(v); s != "" { cfg.EndDelim = s }
-    }
-    fmt.Printf("File size limit (current: %s, e.g., 3 mb): ", cfg.FileSizeLimit)
-    if v, err := r.ReadString('\n'); err == nil {
-        if s := strings.TrimSpace(v); s != "" { cfg.FileSizeLimit = s }
-    }
+    if s := ask(p, prompt.Question{Label: fmt.Sprintf("Template start delimiter (current: %q, e.g., [[])", cfg.StartDelim)}); s != "" { cfg.StartDelim = s }
+    if s := ask(p, prompt.Question{Label: fmt.Sprintf("Template end delimiter (current: %q, e.g., ]])", cfg.EndDelim)}); s != "" { cfg.EndDelim = s }
+    if s := ask(p, prompt.Question{Label: fmt.Sprintf("File size limit (current: %s, e.g., 3 mb)", cfg.FileSizeLimit)}); s != "" { cfg.FileSizeLimit = s }
 
     // Add or edit templates
     for {
-        add := strings.ToLower(prompt(r, "Add a template repo? (y/N)", "n"))
-        if add != "y" && add != "yes" { break }
+        if add, _ := p.Confirm("Add a template repo?", false); !add { break }
         t := domain.TemplateRepo{}
-        fmt.Printf("Template name (label, e.g., 'Go App' or 'org/repo'): ")
-        if v, err := r.ReadString('\n'); err == nil { t.Name = strings.TrimSpace(v) }
-        fmt.Printf("Template git URL (SSH/HTTPS, e.g., git@github.com:org/repo.git or https://github.com/org/repo.git): ")
-        if v, err := r.ReadString('\n'); err == nil { t.URL = strings.TrimSpace(v) }
-        fmt.Printf("Description (optional): ")
-        if v, err := r.ReadString('\n'); err == nil { t.Description = strings.TrimSpace(v) }
-        fmt.Printf("Default branch (e.g., main): ")
-        if v, err := r.ReadString('\n'); err == nil {
-            if s := strings.TrimSpace(v); s != "" { t.DefaultBranch = s } else { t.DefaultBranch = "main" }
-        }
+        t.Name = ask(p, prompt.Question{Label: "Template name (label, e.g., 'Go App' or 'org/repo')"})
+        t.URL = ask(p, prompt.Question{Label: "Template git URL (SSH/HTTPS, e.g., git@github.com:org/repo.git or https://github.com/org/repo.git)"})
+        t.Description = ask(p, prompt.Question{Label: "Description (optional)"})
+        t.DefaultBranch = ask(p, prompt.Question{Label: "Default branch", Default: "main"})
         if t.URL != "" { cfg.Templates = append(cfg.Templates, t) }
     }
 
     // Configure GitHub discovery (optional)
-    if strings.ToLower(prompt(r, "Configure GitHub discovery? (y/N)", "n")) == "y" {
-        cfg.GitHub.User = prompt(r, "GitHub user (leave empty to skip)", cfg.GitHub.User)
-        orgs := prompt(r, "GitHub orgs (comma-separated)", strings.Join(cfg.GitHub.Orgs, ","))
+    if configure, _ := p.Confirm("Configure GitHub discovery?", false); configure {
+        cfg.GitHub.User = ask(p, prompt.Question{Label: "GitHub user (leave empty to skip)", Default: cfg.GitHub.User})
+        orgs := ask(p, prompt.Question{Label: "GitHub orgs (comma-separated)", Default: strings.Join(cfg.GitHub.Orgs, ",")})
         if orgs != "" {
             parts := strings.Split(orgs, ",")
             cfg.GitHub.Orgs = cfg.GitHub.Orgs[:0]
-            for _, p := range parts {
-                p = strings.TrimSpace(p)
-                if p != "" { cfg.GitHub.Orgs = append(cfg.GitHub.Orgs, p) }
+            for _, org := range parts {
+                org = strings.TrimSpace(org)
+                if org != "" { cfg.GitHub.Orgs = append(cfg.GitHub.Orgs, org) }
             }
         }
-        cfg.GitHub.Topic = prompt(r, "Filter by topic (optional)", cfg.GitHub.Topic)
-        cfg.GitHub.Prefix = prompt(r, "Filter by name prefix (optional)", cfg.GitHub.Prefix)
-        if strings.ToLower(prompt(r, "Include private repos? (y/N)", "n")) == "y" {
-            cfg.GitHub.IncludePrivate = true
-        } else {
-            cfg.GitHub.IncludePrivate = false
-        }
-        // token is optional; prompt but don't echo back in --show
-        cfg.GitHub.Token = prompt(r, "GitHub token (optional, for higher rate limits/private)", cfg.GitHub.Token)
+        cfg.GitHub.Topic = ask(p, prompt.Question{Label: "Filter by topic (optional)", Default: cfg.GitHub.Topic})
+        cfg.GitHub.Prefix = ask(p, prompt.Question{Label: "Filter by name prefix (optional)", Default: cfg.GitHub.Prefix})
+        cfg.GitHub.IncludePrivate, _ = p.Confirm("Include private repos?", false)
+        // token is optional; it is not echoed while typed nor shown by --show
+        cfg.GitHub.Token = ask(p, prompt.Question{Label: "GitHub token (optional, for higher rate limits/private)", Default: cfg.GitHub.Token, Secret: true})
     }
 
     if err := services.Save(cfg); err != nil {
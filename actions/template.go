@@ -1,14 +1,12 @@
 package actions
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"sort"
-	"strings"
 
 	"github.com/brasa-ai/yankrun/domain"
 	"github.com/brasa-ai/yankrun/helpers"
+	"github.com/brasa-ai/yankrun/prompt"
 	"github.com/brasa-ai/yankrun/services"
 
 	"github.com/urfave/cli"
@@ -25,7 +23,7 @@ func NewTemplateAction(fs services.FileSystem, parser services.ReplacementParser
 }
 
 func (t *TemplateAction) Execute(c *cli.Context) error {
-	inputFile := c.String("input")
+	inputFiles := c.StringSlice("input")
 	dir := c.String("dir")
 	verbose := c.Bool("verbose")
 	interactive := c.Bool("interactive")
@@ -34,6 +32,16 @@ func (t *TemplateAction) Execute(c *cli.Context) error {
 	fileSizeLimit := c.String("fileSizeLimit")
 	processTemplates := c.Bool("processTemplates")
 	onlyTemplates := c.Bool("onlyTemplates")
+	engine := c.String("engine")
+	suffixFlags := c.StringSlice("templateSuffix")
+	templateInfix := c.Bool("templateInfix")
+	ignorePatterns := c.StringSlice("ignore")
+	sets := c.StringSlice("set")
+	setFiles := c.StringSlice("set-file")
+	explainKeys := c.StringSlice("explain")
+	check := c.Bool("check")
+	prune := c.Bool("prune")
+	dryRun := c.Bool("dry-run") || check
 
 	if dir == "" {
 		return fmt.Errorf("--dir is required for template command")
@@ -43,6 +51,9 @@ func (t *TemplateAction) Execute(c *cli.Context) error {
 	if onlyTemplates && !processTemplates {
 		return fmt.Errorf("--onlyTemplates requires --processTemplates to be set")
 	}
+	if err := checkEngine(engine, processTemplates); err != nil {
+		return err
+	}
 
 	// Load defaults from config
 	cfg, _ := services.Load()
@@ -68,89 +79,125 @@ func (t *TemplateAction) Execute(c *cli.Context) error {
 		fileSizeLimit = "3 mb"
 	}
 
-	var parsed domain.InputReplacement
-	var err error
-	if inputFile != "" {
-		parsed, err = t.parser.Parse(inputFile)
-		if err != nil {
-			return err
-		}
+	// In dry-run mode every write goes to an in-memory overlay
+	replacer, overlay := dryRunReplacer(t.fs, t.replacer, dryRun)
+
+	// Values files, merged in order
+	parsed, sources, err := t.parser.ParseAll(inputFiles)
+	if err != nil {
+		return err
+	}
+
+	// --ignore flags extend the ignore_patterns from the values file
+	parsed.IgnorePath = append(parsed.IgnorePath, ignorePatterns...)
+
+	// YANKRUN_VAR_* environment variables, --set-file and --set override the values file
+	if err := applyOverrides(t.fs, &parsed, sources, os.Environ(), setFiles, sets); err != nil {
+		return err
+	}
+
+	// Template engine, template suffixes and per-file delimiters from the flags, the
+	// config and the values file
+	suffixes := templateSuffixes(suffixFlags, templateInfix, cfg, "")
+	replacer, err = configureReplacer(replacer, cfg, parsed, engine, suffixes)
+	if err != nil {
+		return err
+	}
+
+	// Variable declarations and conditional paths from the yankrun.yaml of the template
+	manifest, err := loadManifest(replacer, dir, &parsed)
+	if err != nil {
+		return err
+	}
+
+	// conditional_paths shape fresh clone and generate output. dir is the user's own tree,
+	// so the paths they exclude are only deleted from it with --prune
+	if !prune && len(parsed.PathConditions) > 0 {
+		helpers.Log.Warn().Msgf("Skipping %d conditional path(s); pass --prune to delete the paths they exclude from %s", len(parsed.PathConditions), dir)
+		parsed.PathConditions = nil
+	}
+
+	// The _partials of dir may be unrelated to yankrun, such as SCSS or Hugo partials; it
+	// is only left out of templating when a file includes a partial
+	usesPartials, err := replacer.UsesPartials(dir, parsed.IgnorePath, fileSizeLimit, startDelim, endDelim)
+	if err != nil {
+		return err
+	}
+	if usesPartials {
+		parsed.IgnorePath = append(parsed.IgnorePath, services.PartialsIgnorePattern)
 	}
 
 	// Analyze placeholders in dir
-	counts, err := t.replacer.AnalyzeDir(dir, fileSizeLimit, startDelim, endDelim, onlyTemplates)
+	placeholders, err := replacer.AnalyzePlaceholders(dir, parsed.IgnorePath, fileSizeLimit, startDelim, endDelim, onlyTemplates)
 	if err != nil {
 		return err
 	}
-	if len(counts) == 0 {
+	if err := addPathConditionRefs(placeholders, parsed); err != nil {
+		return err
+	}
+	if len(placeholders) == 0 {
 		helpers.Log.Info().Msg("No placeholders found.")
-		return nil
+		return finishDryRun(overlay, dir, check)
 	}
 
 	// Merge existing values from parsed file
-	values := map[string]string{}
-	for _, r := range parsed.Variables {
-		values[r.Key] = r.Value
+	inputs := newRunInputs(parsed, manifest, placeholders, sources, startDelim, endDelim)
+	if err := inputs.addDerivedRefs(placeholders); err != nil {
+		return err
+	}
+	addAskIfRefs(manifest, placeholders)
+	warnManifestMismatch(manifest, placeholders, inputs)
+	if err := inputs.applyManifest(manifest, placeholders); err != nil {
+		return err
 	}
 
 	// Pretty print summary
-	keys := make([]string, 0, len(counts))
-	for k := range counts {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	helpers.Log.Info().Msg("Discovered placeholders:")
-	for _, k := range keys {
-		v := values[k]
-		if v == "" {
-			v = "(unset)"
-		}
-		fmt.Printf("  %-24s  matches=%-6d  value=%s\n", k, counts[k], v)
-	}
+	keys := inputs.orderKeys(sortedKeys(placeholders))
+	printSummary(keys, placeholders, inputs)
 
 	// Interactive prompt for missing values
 	if interactive {
-		r := bufio.NewReader(os.Stdin)
-		for _, k := range keys {
-			def := values[k]
-			fmt.Printf("Enter value for %s [%s]: ", k, def)
-			s, _ := r.ReadString('\n')
-			s = strings.TrimSpace(s)
-			if s != "" {
-				values[k] = s
-			}
+		if err := promptInputs(prompt.New(os.Stdin, os.Stdout), keys, placeholders, inputs); err != nil {
+			return err
 		}
-		fmt.Println()
 	}
 
 	// Build replacements with final values (use only discovered keys)
-	final := domain.InputReplacement{}
-	for _, k := range keys {
-		if v, ok := values[k]; ok && v != "" {
-			final.Variables = append(final.Variables, domain.Replacement{Key: k, Value: v})
-		}
+	if err := inputs.resolveDerived(replacer); err != nil {
+		return err
 	}
+	if err := inputs.checkRequired(keys, placeholders); err != nil {
+		return err
+	}
+	final := inputs.final(keys, parsed)
+	inputs.explain(explainKeys, placeholders)
 
-	if len(final.Variables) == 0 {
+	if nothingToApply(final, placeholders) {
 		helpers.Log.Info().Msg("No values provided; nothing to replace.")
-		return nil
+		return finishDryRun(overlay, dir, check)
 	}
 
 	// Skip regular templating if onlyTemplates is set
 	if !onlyTemplates {
-		if err := t.replacer.ReplaceInDir(dir, final, fileSizeLimit, startDelim, endDelim, verbose); err != nil {
+		if err := replacer.ReplaceInDir(dir, withoutTemplates(final, processTemplates, suffixes), fileSizeLimit, startDelim, endDelim, verbose); err != nil {
 			return err
 		}
 	}
 
-	// Process .tpl files if requested
+	// Process template files if requested
 	if processTemplates {
-		if err := t.replacer.ProcessTemplateFiles(dir, final, fileSizeLimit, startDelim, endDelim, verbose); err != nil {
+		if err := replacer.ProcessTemplateFiles(dir, final, fileSizeLimit, startDelim, endDelim, verbose); err != nil {
 			return err
 		}
 		helpers.Log.Info().Msg("Template file processing complete ✔")
 	}
 
+	// dir is the user's own tree, so _partials and yankrun.yaml stay
+
+	if overlay != nil {
+		return finishDryRun(overlay, dir, check)
+	}
+
 	helpers.Log.Info().Msg("Templating complete ✔")
 	return nil
 }
@@ -0,0 +1,376 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/brasa-ai/yankrun/domain"
+	"github.com/brasa-ai/yankrun/helpers"
+	"github.com/brasa-ai/yankrun/prompt"
+	"github.com/brasa-ai/yankrun/services"
+)
+
+// runInputs holds the values gathered for a run, by key, from the values file and prompts.
+type runInputs struct {
+	values  map[string]string
+	lists   map[string][]domain.ListItem
+	derived map[string]string              // values that reference other variables, resolved after the prompts
+	specs   map[string]domain.VariableSpec // manifest declarations of the discovered variables
+	order   []string                       // declared variables, in manifest order
+	sources services.Sources               // where each value came from, for --explain
+
+	startDelim string
+	endDelim   string
+}
+
+// newRunInputs splits the parsed values file into scalar, list and derived values. A
+// value is derived when it references another variable that is set in the values,
+// declared by the manifest or used by a file; other values are kept as they are, so
+// "[[servers]]" stays a literal TOML table header.
+func newRunInputs(in domain.InputReplacement, manifest *domain.Manifest, infos map[string]*services.PlaceholderInfo, sources services.Sources, startDelim, endDelim string) *runInputs {
+	inputs := &runInputs{
+		values:     map[string]string{},
+		lists:      map[string][]domain.ListItem{},
+		derived:    map[string]string{},
+		specs:      map[string]domain.VariableSpec{},
+		sources:    sources,
+		startDelim: startDelim,
+		endDelim:   endDelim,
+	}
+	known := map[string]bool{}
+	for _, r := range in.Variables {
+		known[r.Key] = true
+	}
+	if manifest != nil {
+		for _, v := range manifest.Variables {
+			known[v.Name] = true
+		}
+	}
+	for k := range infos {
+		known[k] = true
+	}
+	for _, r := range in.Variables {
+		isKnown := func(k string) bool { return k != r.Key && known[k] }
+		switch {
+		case r.List != nil:
+			inputs.lists[r.Key] = r.List
+		case services.IsDerived(r.Value, startDelim, endDelim, isKnown):
+			inputs.derived[r.Key] = r.Value
+		default:
+			inputs.values[r.Key] = r.Value
+		}
+	}
+	return inputs
+}
+
+// addDerivedRefs adds the variables that the discovered derived values depend on, so
+// the base inputs are prompted for even when no file uses them directly.
+func (in *runInputs) addDerivedRefs(infos map[string]*services.PlaceholderInfo) error {
+	var queue []string
+	for k := range infos {
+		if _, ok := in.derived[k]; ok {
+			queue = append(queue, k)
+		}
+	}
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		refs, err := services.VariableRefs(in.derived[k], in.startDelim, in.endDelim)
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", k, err)
+		}
+		for _, ref := range refs {
+			if infos[ref] != nil {
+				continue
+			}
+			infos[ref] = &services.PlaceholderInfo{}
+			if _, ok := in.derived[ref]; ok {
+				queue = append(queue, ref)
+			}
+		}
+	}
+	return nil
+}
+
+// summaryValue formats a value for the "Discovered placeholders" summary.
+func (in *runInputs) summaryValue(k string, info *services.PlaceholderInfo) string {
+	switch {
+	case in.values[k] != "" && in.specs[k].Secret:
+		return "(secret)"
+	case in.values[k] != "":
+		return in.values[k]
+	case in.lists[k] != nil:
+		return fmt.Sprintf("[%d items]", len(in.lists[k]))
+	case in.derived[k] != "":
+		return fmt.Sprintf("(derived: %s)", in.derived[k])
+	case info.HasDefault:
+		return fmt.Sprintf("(default: %s)", info.Default)
+	case info.Required:
+		return "(required)"
+	}
+	return "(unset)"
+}
+
+// promptDefault is the value shown in brackets at the prompt and kept when the answer is empty.
+func (in *runInputs) promptDefault(k string, info *services.PlaceholderInfo) string {
+	switch {
+	case in.values[k] != "":
+		return in.values[k]
+	case in.lists[k] != nil:
+		return fmt.Sprintf("[%d items]", len(in.lists[k]))
+	}
+	return info.Default
+}
+
+// printSummary prints the discovered placeholders with their current values, under the
+// titles of their manifest sections. Questions an ask_if condition skips are marked.
+// When some placeholders were found with per-file delimiters, the delimiter styles of
+// every key are shown too.
+func printSummary(keys []string, infos map[string]*services.PlaceholderInfo, in *runInputs) {
+	defaultStyle := services.DelimiterStyle(in.startDelim, in.endDelim)
+	showStyles := false
+	for _, info := range infos {
+		for _, style := range info.Styles {
+			showStyles = showStyles || style != defaultStyle
+		}
+	}
+
+	helpers.Log.Info().Msg("Discovered placeholders:")
+	var section *domain.Section
+	for _, k := range keys {
+		if s := in.specs[k].Section; s != nil && s != section {
+			sectionHeading(s, "  ")
+			section = s
+		}
+		value := in.summaryValue(k, infos[k])
+		if reason := in.skipReason(k); reason != "" && (value == "(unset)" || value == "(required)") {
+			// a skipped question is not required
+			value = fmt.Sprintf("(skipped: %s)", reason)
+		} else if reason != "" {
+			value += fmt.Sprintf(" (skipped: %s)", reason)
+		}
+		if showStyles {
+			fmt.Printf("  %-24s  matches=%-6d  delims=%-10s  value=%s\n", k, infos[k].Count, strings.Join(infos[k].Styles, ","), value)
+			continue
+		}
+		fmt.Printf("  %-24s  matches=%-6d  value=%s\n", k, infos[k].Count, value)
+	}
+}
+
+// promptInputs asks for every discovered key except derived ones and those an ask_if
+// condition skips; an empty answer keeps the current value. When the input ends, the
+// remaining keys keep theirs.
+func promptInputs(p *prompt.Prompter, keys []string, infos map[string]*services.PlaceholderInfo, in *runInputs) error {
+	var section, skipped *domain.Section
+	for _, k := range keys {
+		if _, ok := in.derived[k]; ok {
+			continue
+		}
+		spec := in.specs[k]
+		if reason := in.skipReason(k); reason != "" {
+			switch {
+			case spec.Section != nil && reason == spec.Section.AskIf:
+				if spec.Section != skipped {
+					fmt.Printf("Skipped %s (ask_if: %s)\n", spec.Section.Title, reason)
+					skipped = spec.Section
+				}
+			default:
+				fmt.Printf("Skipped %s (ask_if: %s)\n", k, reason)
+			}
+			continue
+		}
+		if spec.Section != nil && spec.Section != section {
+			fmt.Println()
+			sectionHeading(spec.Section, "")
+			section = spec.Section
+		}
+		answer, err := p.Ask(in.question(k, infos[k]))
+		if answer != "" {
+			in.values[k] = answer
+			in.sources.Add(k, "prompt", domain.Replacement{Value: answer})
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// ask asks q and returns its default when the answer is empty or the input has ended.
+func ask(p *prompt.Prompter, q prompt.Question) string {
+	if v, _ := p.Ask(q); v != "" {
+		return v
+	}
+	return q.Default
+}
+
+// resolveDerived computes the derived values from the final base values.
+func (in *runInputs) resolveDerived(replacer services.Replacer) error {
+	if len(in.derived) == 0 {
+		return nil
+	}
+	values, err := replacer.ResolveDerived(in.values, in.derived, in.startDelim, in.endDelim)
+	if err != nil {
+		return err
+	}
+	in.values = values
+	return nil
+}
+
+// checkRequired fails with the locations of every [[KEY!]] placeholder left without a value.
+func (in *runInputs) checkRequired(keys []string, infos map[string]*services.PlaceholderInfo) error {
+	var missing []string
+	for _, k := range keys {
+		info := infos[k]
+		if !info.Required || info.HasDefault || in.values[k] != "" || in.lists[k] != nil || in.skipReason(k) != "" {
+			continue
+		}
+		missing = append(missing, fmt.Sprintf("  %s (%s)", k, strings.Join(info.RequiredAt, ", ")))
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("required values missing:\n%s", strings.Join(missing, "\n"))
+	}
+	return nil
+}
+
+// final builds the replacements for the discovered keys. A value entered at the
+// prompt takes precedence over a list from the values file, and both over the
+// default of the manifest.
+func (in *runInputs) final(keys []string, provided domain.InputReplacement) domain.InputReplacement {
+	final := domain.InputReplacement{IgnorePath: provided.IgnorePath, PathConditions: provided.PathConditions}
+	for _, k := range keys {
+		if v, ok := in.values[k]; ok && v != "" {
+			final.Variables = append(final.Variables, domain.Replacement{Key: k, Value: v})
+		} else if l, ok := in.lists[k]; ok {
+			final.Variables = append(final.Variables, domain.Replacement{Key: k, List: l})
+		} else if spec := in.specs[k]; spec.HasDefault() {
+			final.Variables = append(final.Variables, spec.Default)
+		}
+	}
+	return final
+}
+
+// sortedKeys returns the discovered placeholder keys in alphabetical order.
+func sortedKeys(infos map[string]*services.PlaceholderInfo) []string {
+	keys := make([]string, 0, len(infos))
+	for k := range infos {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// addPathConditionRefs counts the variables used by conditional_paths rules as
+// discovered placeholders, so they show up in the summary and prompts.
+func addPathConditionRefs(infos map[string]*services.PlaceholderInfo, provided domain.InputReplacement) error {
+	refs, err := services.PathConditionRefs(provided.PathConditions)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if infos[ref] == nil {
+			infos[ref] = &services.PlaceholderInfo{}
+		}
+		infos[ref].Count++
+	}
+	return nil
+}
+
+// nothingToApply reports whether a run would leave the tree untouched: no values,
+// no inline defaults and no conditional paths.
+func nothingToApply(final domain.InputReplacement, infos map[string]*services.PlaceholderInfo) bool {
+	if len(final.Variables) > 0 || len(final.PathConditions) > 0 {
+		return false
+	}
+	for _, info := range infos {
+		if info.HasDefault {
+			return false
+		}
+	}
+	return true
+}
+
+// finishOutput ends every successful clone or generate run, including those with
+// nothing to replace: the partials and the manifest loaded for the run describe the
+// template, they are not part of the fresh output. In dry-run mode it then reports the
+// planned changes.
+func finishOutput(replacer services.Replacer, overlay *services.OverlayFileSystem, manifest *domain.Manifest, dir string, verbose, check bool) error {
+	if err := replacer.RemovePartials(dir, verbose); err != nil {
+		return err
+	}
+	if manifest != nil {
+		if err := replacer.RemoveManifest(dir, verbose); err != nil {
+			return err
+		}
+	}
+	return finishDryRun(overlay, dir, check)
+}
+
+// withoutTemplates returns the replacements for the regular pass when template files
+// are processed separately, so each template is rendered only once and escaped
+// delimiters are not unescaped twice.
+func withoutTemplates(final domain.InputReplacement, processTemplates bool, ts services.TemplateSuffixes) domain.InputReplacement {
+	if !processTemplates {
+		return final
+	}
+	final.IgnorePath = append(append([]string{}, final.IgnorePath...), ts.IgnorePatterns()...)
+	return final
+}
+
+// templateSuffixes returns the suffixes of template files: those of --templateSuffix,
+// else those configured for the template repo at repoURL, else the global ones.
+func templateSuffixes(flags []string, infix bool, cfg *domain.Config, repoURL string) services.TemplateSuffixes {
+	ts := services.TemplateSuffixes{Suffixes: cfg.TemplateSuffixes, Infix: infix || cfg.TemplateInfix}
+	for _, t := range cfg.Templates {
+		if repoURL != "" && t.URL == repoURL && len(t.TemplateSuffixes) > 0 {
+			ts = services.TemplateSuffixes{Suffixes: t.TemplateSuffixes, Infix: infix || t.TemplateInfix}
+		}
+	}
+	var fromFlags []string
+	for _, f := range flags {
+		fromFlags = append(fromFlags, strings.Split(f, ",")...)
+	}
+	if len(fromFlags) > 0 {
+		ts.Suffixes = fromFlags
+	}
+	return ts
+}
+
+// configureReplacer sets the engine and the suffixes of template files and applies the
+// per-file delimiters of the config followed by those of the values file, so a values
+// file rule wins over a config rule for the same files.
+func configureReplacer(replacer services.Replacer, cfg *domain.Config, in domain.InputReplacement, engine string, ts services.TemplateSuffixes) (services.Replacer, error) {
+	replacer, err := replacer.WithEngine(engine)
+	if err != nil {
+		return nil, err
+	}
+	if replacer, err = replacer.WithTemplateSuffixes(ts); err != nil {
+		return nil, err
+	}
+	if len(cfg.Delimiters) == 0 && len(in.Delimiters) == 0 {
+		return replacer, nil
+	}
+	rules := append(append([]domain.DelimiterRule{}, cfg.Delimiters...), in.Delimiters...)
+	return replacer.WithDelimiters(rules)
+}
+
+// checkEngine validates --engine before anything is cloned or written.
+func checkEngine(engine string, processTemplates bool) error {
+	switch engine {
+	case "", services.EngineYankrun:
+		return nil
+	case services.EngineGoTemplate:
+		if !processTemplates {
+			return fmt.Errorf("--engine=%s requires --processTemplates to be set", engine)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown --engine %q: expected %s or %s", engine, services.EngineYankrun, services.EngineGoTemplate)
+}
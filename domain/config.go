@@ -4,6 +4,9 @@ type Config struct {
     StartDelim    string `yaml:"start_delim"`
     EndDelim      string `yaml:"end_delim"`
     FileSizeLimit string `yaml:"file_size_limit"`
+    Delimiters       []DelimiterRule `yaml:"delimiters"`        // per-file delimiters, before those of the values file
+    TemplateSuffixes []string        `yaml:"template_suffixes"` // suffixes of template files, .tpl when empty
+    TemplateInfix    bool            `yaml:"template_infix"`    // also match template suffixes before the extension
     Templates     []TemplateRepo `yaml:"templates"`
     GitHub        GitHubConfig   `yaml:"github"`
 }
@@ -13,6 +16,8 @@ type TemplateRepo struct {
     URL         string `yaml:"url"`
     Description string `yaml:"description"`
     DefaultBranch string `yaml:"default_branch"`
+    TemplateSuffixes []string `yaml:"template_suffixes"` // overrides the global template_suffixes for this repo
+    TemplateInfix    bool     `yaml:"template_infix"`
 }
 
 type GitHubConfig struct {
@@ -0,0 +1,46 @@
+package domain
+
+// Variable types of a VariableSpec.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeBool   = "bool"
+	TypeEnum   = "enum"
+	TypeList   = "list"
+)
+
+// Manifest is the yankrun.yaml file at the root of a template. It declares the
+// variables of the template, in the order they are asked for.
+type Manifest struct {
+	Variables      []VariableSpec // ungrouped variables first, then those of each section
+	Sections       []*Section
+	PathConditions []PathCondition
+}
+
+// Section groups variables that are asked for under a heading.
+type Section struct {
+	Title       string
+	Description string
+	AskIf       string // condition on earlier answers; the whole section is skipped when false
+}
+
+// VariableSpec declares one template variable.
+type VariableSpec struct {
+	Name        string
+	Description string
+	Type        string      // one of the Type constants; string when not set, enum when Choices are set
+	Default     Replacement // Value, or List for lists; used when no value is given
+	Pattern     string      // regular expression the whole value, or each list item, must match
+	Choices     []string    // allowed values of an enum, or of the items of a list
+	Min, Max    *int        // bounds of an int, when set
+	Required    bool
+	Secret      bool     // never shown in the summary or at the prompt
+	Multiline   bool     // asked for over several lines
+	Section     *Section // nil for ungrouped variables
+	AskIf       string   // condition on earlier answers; the variable is skipped when false
+}
+
+// HasDefault reports whether the variable declares a default value.
+func (v VariableSpec) HasDefault() bool {
+	return v.Default.Value != "" || v.Default.List != nil
+}
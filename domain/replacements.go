@@ -1,13 +1,38 @@
 package domain
 
 type Replacement struct {
-	Key             string   `json:"key" yaml:"key"`
-	Value           string   `json:"value" yaml:"value"`
-	BaseKey         string   `json:"-" yaml:"-"` // Not marshalled, used internally
-	Transformations []string `json:"-" yaml:"-"` // Not marshalled, used internally
+	Key             string     `json:"key" yaml:"key"`
+	Value           string     `json:"value" yaml:"value"`
+	List            []ListItem `json:"-" yaml:"-"` // Set when the value is a list, filled by the parser
+	BaseKey         string     `json:"-" yaml:"-"` // Not marshalled, used internally
+	Transformations []string   `json:"-" yaml:"-"` // Not marshalled, used internally
+}
+
+// ListItem is one element of a list-valued variable: either a scalar Value
+// or, for lists of maps, a set of Fields.
+type ListItem struct {
+	Value  string
+	Fields map[string]string
+}
+
+// PathCondition keeps the files and directories matching Path (gitignore syntax)
+// only when the When condition holds; otherwise they are removed from the output.
+type PathCondition struct {
+	Path string `json:"path" yaml:"path"`
+	When string `json:"when" yaml:"when"`
+}
+
+// DelimiterRule makes the files matching Path (gitignore syntax) use the Start and
+// End delimiters instead of the default ones.
+type DelimiterRule struct {
+	Path  string `json:"path" yaml:"path"`
+	Start string `json:"start" yaml:"start"`
+	End   string `json:"end" yaml:"end"`
 }
 
 type InputReplacement struct {
-	Variables  []Replacement `json:"variables" yaml:"variables"`
-	IgnorePath []string      `json:"ignore_patterns" yaml:"ignore_patterns"`
+	Variables      []Replacement   `json:"variables" yaml:"variables"`
+	IgnorePath     []string        `json:"ignore_patterns" yaml:"ignore_patterns"`
+	PathConditions []PathCondition `json:"conditional_paths" yaml:"conditional_paths"`
+	Delimiters     []DelimiterRule `json:"delimiters" yaml:"delimiters"`
 }
@@ -2,10 +2,9 @@ package main
 
 import "github.com/urfave/cli"
 
-var inputFlag = cli.StringFlag{
+var inputFlag = cli.StringSliceFlag{
 	Name:  "input, i",
-	Value: "",
-	Usage: "Input file with values for replacement",
+	Usage: "Values file or directory of values files (repeatable; later files override earlier ones)",
 }
 
 var repoFlag = cli.StringFlag{
@@ -68,10 +67,61 @@ var templateNameFlag = cli.StringFlag{
 
 var processTemplatesFlag = cli.BoolFlag{
 	Name:  "processTemplates, pt",
-	Usage: "Process .tpl files by evaluating templates and removing .tpl suffix",
+	Usage: "Process template files (.tpl by default) by evaluating templates and removing their suffix",
 }
 
 var onlyTemplatesFlag = cli.BoolFlag{
 	Name:  "onlyTemplates, ot",
-	Usage: "When used with --processTemplates, only process .tpl files and ignore all other files",
+	Usage: "When used with --processTemplates, only process template files and ignore all other files",
+}
+
+var engineFlag = cli.StringFlag{
+	Name:  "engine",
+	Value: "",
+	Usage: "Engine for template files with --processTemplates: yankrun (default) or gotemplate (Go text/template)",
+}
+
+var templateSuffixFlag = cli.StringSliceFlag{
+	Name:  "templateSuffix, ts",
+	Usage: "Suffix marking template files for --processTemplates, e.g. .tmpl (repeatable or comma-separated, default .tpl)",
+}
+
+var templateInfixFlag = cli.BoolFlag{
+	Name:  "templateInfix",
+	Usage: "Also process template suffixes before the extension (config.tpl.yaml -> config.yaml)",
+}
+
+var setFlag = cli.StringSliceFlag{
+	Name:  "set",
+	Usage: "Set a variable, KEY=VALUE (repeatable, overrides --input and YANKRUN_VAR_KEY)",
+}
+
+var setFileFlag = cli.StringSliceFlag{
+	Name:  "set-file, setFile",
+	Usage: "Set a variable to the content of a file, KEY=path (repeatable, overrides --input and YANKRUN_VAR_KEY)",
+}
+
+var explainFlag = cli.StringSliceFlag{
+	Name:  "explain",
+	Usage: "Report which values file, environment variable, flag or answer supplied the value of KEY (repeatable)",
+}
+
+var ignoreFlag = cli.StringSliceFlag{
+	Name:  "ignore",
+	Usage: "Gitignore-style pattern to skip (repeatable, merged with ignore_patterns from --input)",
+}
+
+var dryRunFlag = cli.BoolFlag{
+	Name:  "dry-run, dryRun",
+	Usage: "Run the full pipeline in memory and print a unified diff instead of writing files",
+}
+
+var checkFlag = cli.BoolFlag{
+	Name:  "check",
+	Usage: "Implies --dry-run; exit non-zero when any file would change",
+}
+
+var pruneFlag = cli.BoolFlag{
+	Name:  "prune",
+	Usage: "Delete the paths excluded by conditional_paths from --dir (clone and generate always drop them from their output)",
 }
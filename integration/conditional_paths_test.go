@@ -0,0 +1,44 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateConditionalPathsNeedPrune(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "main.go", "package [[NAME]]\n")
+	if err := os.MkdirAll(filepath.Join(workDir, "helm"), 0755); err != nil {
+		t.Fatalf("Failed to create helm dir: %v", err)
+	}
+	writeFile(t, workDir, "helm/values.yaml", "name: [[NAME]]\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", "variables: [{key: NAME, value: demo}]\nconditional_paths: [{path: helm/, when: K8S}]\n")
+
+	// without --prune the excluded paths stay in the user's tree
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath)
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	if !strings.Contains(string(out), "--prune") {
+		t.Errorf("expected a warning about --prune.\nFull output:\n%s", string(out))
+	}
+	if content, _ := os.ReadFile(filepath.Join(workDir, "helm/values.yaml")); string(content) != "name: demo\n" {
+		t.Errorf("helm/values.yaml should be kept and templated, got:\n%s", string(content))
+	}
+
+	cmd = exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--prune")
+	cmd.Dir = repoRoot(t)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("template --prune failed: %v\n%s", err, string(out))
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "helm")); !os.IsNotExist(err) {
+		t.Errorf("helm/ should be deleted with --prune, got %v", err)
+	}
+}
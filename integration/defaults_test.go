@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateDefaultsAndRequired(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	appFile := writeFile(t, workDir, "app.yaml", "name: [[APP_NAME]]\nport: [[PORT|8080]]\n")
+	if err := os.MkdirAll(filepath.Join(workDir, "config"), 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	writeFile(t, workDir, "config/api.env", "# api\nAPI_URL=[[API_URL!]]\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: APP_NAME, value: demo}]`)
+
+	// API_URL is required and unset: the run fails without touching any file
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath)
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected a missing required value to fail the run\n%s", string(out))
+	}
+	for _, want := range []string{"PORT", "(default: 8080)", "(required)", "required values missing", "API_URL (" + filepath.Join(workDir, "config", "api.env") + ":2)"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected output to contain %q.\nFull output:\n%s", want, string(out))
+		}
+	}
+	content, _ := os.ReadFile(appFile)
+	if string(content) != "name: [[APP_NAME]]\nport: [[PORT|8080]]\n" {
+		t.Errorf("app.yaml should be untouched, got:\n%s", string(content))
+	}
+
+	valsPath = writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: APP_NAME, value: demo}, {key: API_URL, value: "https://api.example.com"}]`)
+	cmd = exec.Command(bin, "template", "--dir", workDir, "--input", valsPath)
+	cmd.Dir = repoRoot(t)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	content, _ = os.ReadFile(appFile)
+	if string(content) != "name: demo\nport: 8080\n" {
+		t.Errorf("app.yaml mismatch, got:\n%s", string(content))
+	}
+	content, _ = os.ReadFile(filepath.Join(workDir, "config", "api.env"))
+	if string(content) != "# api\nAPI_URL=https://api.example.com\n" {
+		t.Errorf("api.env mismatch, got:\n%s", string(content))
+	}
+}
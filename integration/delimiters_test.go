@@ -0,0 +1,51 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplatePerFileDelimiters(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "deploy.sh", "if [[ -f app ]]; then echo <%APP_NAME%>; fi\n")
+	writeFile(t, workDir, "app.yaml", "name: [[APP_NAME]]\nport: [[PORT]]\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables:
+  - key: APP_NAME
+    value: demo
+  - key: PORT
+    value: "8080"
+delimiters:
+  - path: "*.sh"
+    start: "<%"
+    end: "%>"
+`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath)
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	for _, want := range []string{"delims=[[ ]],<% %>", "delims=[[ ]]"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected output to contain %q.\nFull output:\n%s", want, string(out))
+		}
+	}
+	if strings.Contains(string(out), "-f app") {
+		t.Errorf("the bash test should not be discovered as a placeholder:\n%s", string(out))
+	}
+
+	content, _ := os.ReadFile(filepath.Join(workDir, "deploy.sh"))
+	if string(content) != "if [[ -f app ]]; then echo demo; fi\n" {
+		t.Errorf("deploy.sh mismatch, got:\n%s", string(content))
+	}
+	content, _ = os.ReadFile(filepath.Join(workDir, "app.yaml"))
+	if string(content) != "name: demo\nport: 8080\n" {
+		t.Errorf("app.yaml mismatch, got:\n%s", string(content))
+	}
+}
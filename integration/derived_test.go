@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestTemplateDerivedVariables(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	goMod := writeFile(t, workDir, "go.mod", "module [[MODULE_PATH]]\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables:
+  - key: ORG
+    value: acme
+  - key: MODULE_PATH
+    value: "github.com/[[ORG]]/[[APP_NAME:toLowerCase]]"
+`)
+
+	// only the base inputs are prompted for: APP_NAME (new) and ORG (keeps its value)
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--prompt")
+	cmd.Dir = repoRoot(t)
+	cmd.Stdin = strings.NewReader("My-App\n\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	if strings.Contains(string(out), "Enter value for MODULE_PATH") {
+		t.Errorf("derived variables should not be prompted for.\nFull output:\n%s", string(out))
+	}
+	for _, want := range []string{"(derived: github.com/[[ORG]]/[[APP_NAME:toLowerCase]])", "Enter value for APP_NAME", "Enter value for ORG [acme]"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected output to contain %q.\nFull output:\n%s", want, string(out))
+		}
+	}
+
+	content, _ := os.ReadFile(goMod)
+	if string(content) != "module github.com/acme/my-app\n" {
+		t.Errorf("go.mod mismatch, got %q", string(content))
+	}
+}
+
+func TestTemplateLiteralBracketValues(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	config := writeFile(t, workDir, "config.toml", "[[TABLE]]\nname = \"[[NAME]]\"\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables:
+  - key: TABLE
+    value: "[[servers]]"
+  - key: NAME
+    value: "[[TABLE]]"
+`)
+
+	// TABLE references no known variable, so it is a plain value; NAME references TABLE
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath)
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	if !strings.Contains(string(out), "(derived: [[TABLE]])") || strings.Contains(string(out), "(derived: [[servers]])") {
+		t.Errorf("only NAME should be derived.\nFull output:\n%s", string(out))
+	}
+
+	content, _ := os.ReadFile(config)
+	if string(content) != "[[servers]]\nname = \"[[servers]]\"\n" {
+		t.Errorf("config.toml mismatch, got %q", string(content))
+	}
+}
@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateDryRun(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	original := "name: [[APP_NAME]]\nversion: 1\n"
+	appFile := writeFile(t, workDir, "app.yaml", original)
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: APP_NAME, value: demo}]`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--dry-run")
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("dry run failed: %v\n%s", err, string(out))
+	}
+	for _, want := range []string{"M app.yaml", "--- a/app.yaml", "+++ b/app.yaml", "-name: [[APP_NAME]]", "+name: demo"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected output to contain %q.\nFull output:\n%s", want, string(out))
+		}
+	}
+
+	content, err := os.ReadFile(appFile)
+	if err != nil {
+		t.Fatalf("Failed to read app.yaml: %v", err)
+	}
+	if string(content) != original {
+		t.Errorf("dry run should not modify files, got:\n%s", string(content))
+	}
+
+	// --check fails while changes are pending
+	check := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--check")
+	check.Dir = repoRoot(t)
+	if out, err := check.CombinedOutput(); err == nil {
+		t.Fatalf("expected --check to fail when changes are pending\n%s", string(out))
+	}
+
+	// apply for real, then --check passes
+	apply := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath)
+	apply.Dir = repoRoot(t)
+	if out, err := apply.CombinedOutput(); err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "app.yaml")); err != nil {
+		t.Fatalf("app.yaml missing after templating: %v", err)
+	}
+	check = exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--check")
+	check.Dir = repoRoot(t)
+	if out, err := check.CombinedOutput(); err != nil {
+		t.Fatalf("expected --check to pass once templated: %v\n%s", err, string(out))
+	}
+}
+
+func TestTemplateDryRunWithNothingToReplace(t *testing.T) {
+	bin := buildBinary(t)
+	noPlaceholders := t.TempDir()
+	writeFile(t, noPlaceholders, "plain.txt", "no placeholders\n")
+	noValues := t.TempDir()
+	writeFile(t, noValues, "app.yaml", "name: [[APP_NAME]]\n")
+
+	// runs that stop early still end with the dry-run report
+	for _, dir := range []string{noPlaceholders, noValues} {
+		cmd := exec.Command(bin, "template", "--dir", dir, "--check")
+		cmd.Dir = repoRoot(t)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("--check failed: %v\n%s", err, string(out))
+		}
+		if !strings.Contains(string(out), "Dry run: no changes") {
+			t.Errorf("expected the dry run to be reported.\nFull output:\n%s", string(out))
+		}
+	}
+}
+
+func TestTemplateDryRunReportsModeChanges(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	// same content as the existing file, only the mode changes
+	writeFile(t, workDir, "run.sh.tpl", "---\nmode: 0755\n---\n#!/bin/sh\necho [[APP_NAME]]\n")
+	script := writeFile(t, workDir, "run.sh", "#!/bin/sh\necho demo\n")
+	if err := os.Chmod(script, 0644); err != nil {
+		t.Fatalf("Failed to chmod run.sh: %v", err)
+	}
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: APP_NAME, value: demo}]`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--processTemplates", "--check")
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected --check to fail on a mode change.\nFull output:\n%s", string(out))
+	}
+	for _, want := range []string{"M run.sh", "old mode 100644", "new mode 100755"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected output to contain %q.\nFull output:\n%s", want, string(out))
+		}
+	}
+	if info, err := os.Stat(script); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("dry run should not change the mode of run.sh, got %v (%v)", info, err)
+	}
+}
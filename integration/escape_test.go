@@ -0,0 +1,38 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateEscapedDelimiters(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "check.sh", "if \\[[ -f \"$F\" ]]; then echo [[APP_NAME]]; fi\n")
+	writeFile(t, workDir, "servers.toml.tpl", "[[raw]]\n[[servers]]\n[[/raw]]\nname = \"[[APP_NAME]]\"\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: APP_NAME, value: demo}]`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--processTemplates")
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	if strings.Contains(string(out), "servers") || strings.Contains(string(out), "-f") {
+		t.Errorf("escaped delimiters should not be discovered as placeholders:\n%s", string(out))
+	}
+
+	content, _ := os.ReadFile(filepath.Join(workDir, "check.sh"))
+	if string(content) != "if [[ -f \"$F\" ]]; then echo demo; fi\n" {
+		t.Errorf("check.sh mismatch, got:\n%s", string(content))
+	}
+	// rendered once: the unescaped [[servers]] is not treated as a placeholder again
+	content, _ = os.ReadFile(filepath.Join(workDir, "servers.toml"))
+	if string(content) != "[[servers]]\nname = \"demo\"\n" {
+		t.Errorf("servers.toml mismatch, got:\n%s", string(content))
+	}
+}
@@ -0,0 +1,50 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateFrontMatter(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "run.sh.tpl", "---\ndest: /bin/[[APP_NAME]]\nmode: 0755\n---\n#!/bin/sh\necho [[APP_NAME]]\n")
+	writeFile(t, workDir, "db.yaml.tpl", "---\nwhen: USE_DB\n---\ndb: [[APP_NAME]]\n")
+	writeFile(t, workDir, "settings.json.tpl", "---\noverwrite: never\n---\n{\"name\": \"[[APP_NAME]]\"}\n")
+	writeFile(t, workDir, "settings.json", "{}\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: APP_NAME, value: demo}, {key: USE_DB, value: "false"}]`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--processTemplates", "--verbose")
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	if !strings.Contains(string(out), "USE_DB") {
+		t.Errorf("expected the when condition to be reported.\nFull output:\n%s", string(out))
+	}
+	if !strings.Contains(string(out), "Kept existing") {
+		t.Errorf("expected settings.json to be kept.\nFull output:\n%s", string(out))
+	}
+
+	info, err := os.Stat(filepath.Join(workDir, "bin", "demo"))
+	if err != nil || info.Mode().Perm() != 0755 {
+		t.Fatalf("expected an executable bin/demo, got %v (%v)", info, err)
+	}
+	content, _ := os.ReadFile(filepath.Join(workDir, "bin", "demo"))
+	if string(content) != "#!/bin/sh\necho demo\n" {
+		t.Errorf("bin/demo mismatch, got:\n%s", string(content))
+	}
+	if content, _ := os.ReadFile(filepath.Join(workDir, "settings.json")); string(content) != "{}\n" {
+		t.Errorf("settings.json should be kept, got:\n%s", string(content))
+	}
+	for _, name := range []string{"db.yaml", "db.yaml.tpl", "run.sh", "settings.json.tpl"} {
+		if _, err := os.Stat(filepath.Join(workDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should not exist, got %v", name, err)
+		}
+	}
+}
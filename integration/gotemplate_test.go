@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateGoTemplateEngine(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "services.txt.tpl", "[[ range .SERVICES ]][[ .name | toUpperCase ]]:[[ .port ]]\n[[ end ]][[ if .USE_DB ]]db\n[[ end ]]")
+	writeFile(t, workDir, "README.md", "# [[APP_NAME]]\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables:
+  - key: APP_NAME
+    value: demo
+  - key: USE_DB
+    value: false
+  - key: SERVICES
+    value:
+      - name: api
+        port: 8080
+`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--engine", "gotemplate")
+	cmd.Dir = repoRoot(t)
+	if out, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), "--engine=gotemplate requires --processTemplates") {
+		t.Fatalf("expected --engine without --processTemplates to fail\n%s", string(out))
+	}
+
+	cmd = exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--processTemplates", "--engine", "gotemplate")
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	for _, want := range []string{"SERVICES", "USE_DB", "APP_NAME"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected output to contain %q.\nFull output:\n%s", want, string(out))
+		}
+	}
+
+	content, _ := os.ReadFile(filepath.Join(workDir, "services.txt"))
+	if string(content) != "API:8080\n" {
+		t.Errorf("services.txt mismatch, got:\n%s", string(content))
+	}
+	content, _ = os.ReadFile(filepath.Join(workDir, "README.md"))
+	if string(content) != "# demo\n" {
+		t.Errorf("README.md mismatch, got:\n%s", string(content))
+	}
+}
@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateLayeredInputs(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+	valsDir := t.TempDir()
+	defaults := filepath.Join(valsDir, "defaults")
+	if err := os.Mkdir(defaults, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, workDir, "app.txt", "[[APP]] [[ORG]] [[PORT|80]]\n")
+	if err := os.Mkdir(filepath.Join(workDir, "dist"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(workDir, "dist"), "out.txt", "[[APP]]\n")
+	org := writeFile(t, defaults, "10-org.yaml", "variables:\n  - {key: APP, value: org-app}\n  - {key: ORG, value: acme}\n")
+	team := writeFile(t, defaults, "20-team.json", `{"variables": [{"key": "APP", "value": "team-app"}], "ignore_patterns": ["dist/"]}`)
+	project := writeFile(t, valsDir, "project.yaml", "variables:\n  - {key: APP, value: demo}\n")
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", defaults, "--input", project, "--explain", "APP", "--explain", "PORT")
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+
+	content, _ := os.ReadFile(filepath.Join(workDir, "app.txt"))
+	if string(content) != "demo acme 80\n" {
+		t.Errorf("app.txt mismatch, got:\n%s", string(content))
+	}
+	// ignore_patterns of every file apply
+	if content, _ := os.ReadFile(filepath.Join(workDir, "dist", "out.txt")); string(content) != "[[APP]]\n" {
+		t.Errorf("dist/out.txt should be ignored, got:\n%s", string(content))
+	}
+
+	explained := string(out)[strings.Index(string(out), "Explain APP"):]
+	var lines []string
+	for _, line := range strings.Split(explained, "\n")[1:4] {
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	want := []string{org + " org-app", team + " team-app", project + " demo <- used"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected APP to be explained as\n%s\ngot\n%s\nFull output:\n%s", strings.Join(want, "\n"), strings.Join(lines, "\n"), string(out))
+	}
+	if !strings.Contains(string(out), "Explain PORT:") || !strings.Contains(strings.Join(strings.Fields(string(out)), " "), "inline default 80 <- used") {
+		t.Errorf("expected PORT to be explained.\nFull output:\n%s", string(out))
+	}
+}
@@ -0,0 +1,205 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testManifest = `variables:
+  - name: APP_NAME
+    pattern: "[a-z][a-z0-9-]*"
+    required: true
+  - name: DB
+    choices: [postgres, mysql, sqlite]
+    default: postgres
+  - name: PORT
+    type: int
+    default: 8080
+  - name: UNUSED
+`
+
+func TestTemplateManifest(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "yankrun.yaml", testManifest)
+	writeFile(t, workDir, "config.yaml", "name: [[APP_NAME]]\ndb: [[DB]]\nport: [[PORT]]\nowner: [[OWNER]]\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: APP_NAME, value: demo}, {key: OWNER, value: me}]`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath)
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	for _, want := range []string{"declares UNUSED, but no file uses it", "OWNER is used but not declared"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %q in the output.\nFull output:\n%s", want, string(out))
+		}
+	}
+
+	content, _ := os.ReadFile(filepath.Join(workDir, "config.yaml"))
+	if string(content) != "name: demo\ndb: postgres\nport: 8080\nowner: me\n" {
+		t.Errorf("config.yaml mismatch, got:\n%s", string(content))
+	}
+	// the tree is templated in place, so the manifest is left alone
+	if content, _ := os.ReadFile(filepath.Join(workDir, "yankrun.yaml")); string(content) != testManifest {
+		t.Errorf("yankrun.yaml should be kept in place, got:\n%s", string(content))
+	}
+}
+
+func TestTemplateManifestRejectsInvalidValues(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "yankrun.yaml", testManifest)
+	writeFile(t, workDir, "config.yaml", "name: [[APP_NAME]]\ndb: [[DB]]\nport: [[PORT]]\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: APP_NAME, value: Demo}, {key: DB, value: oracle}, {key: PORT, value: "80"}]`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath)
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected template to fail.\nFull output:\n%s", string(out))
+	}
+	for _, want := range []string{"APP_NAME: must match", "DB: must be one of postgres, mysql, sqlite"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %q in the output.\nFull output:\n%s", want, string(out))
+		}
+	}
+	if strings.Contains(string(out), "PORT:") {
+		t.Errorf("PORT is valid and should not be reported.\nFull output:\n%s", string(out))
+	}
+	if content, _ := os.ReadFile(filepath.Join(workDir, "config.yaml")); !strings.Contains(string(content), "[[APP_NAME]]") {
+		t.Errorf("config.yaml should be left alone, got:\n%s", string(content))
+	}
+}
+
+func TestTemplateManifestRequired(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "yankrun.yaml", testManifest)
+	writeFile(t, workDir, "config.yaml", "name: [[APP_NAME]]\n")
+
+	cmd := exec.Command(bin, "template", "--dir", workDir)
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "APP_NAME (yankrun.yaml)") {
+		t.Fatalf("expected APP_NAME to be reported as required by yankrun.yaml, got %v.\nFull output:\n%s", err, string(out))
+	}
+}
+
+func TestTemplateManifestPrompts(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "yankrun.yaml", `variables:
+  - name: DB
+    description: Database engine
+    choices: [postgres, mysql, sqlite]
+  - name: PORT
+    type: int
+    min: 1
+    max: 65535
+  - name: DEBUG
+    type: bool
+  - name: NOTES
+    multiline: true
+`)
+	writeFile(t, workDir, "config.yaml", "db: [[DB]]\nport: [[PORT]]\ndebug: [[DEBUG]]\nnotes: |\n[[NOTES]]\n")
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--prompt")
+	cmd.Dir = repoRoot(t)
+	cmd.Stdin = strings.NewReader("2\n99999\n8080\nyes\n  first\n  second\n.\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	for _, want := range []string{"Database engine\n  [1] postgres\n  [2] mysql\n  [3] sqlite\n", "(1-3)", "(integer 1-65535)", "must be at most 65535, got 99999", "(y/n)"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %q in the output.\nFull output:\n%s", want, string(out))
+		}
+	}
+
+	content, _ := os.ReadFile(filepath.Join(workDir, "config.yaml"))
+	if string(content) != "db: mysql\nport: 8080\ndebug: true\nnotes: |\n  first\n  second\n" {
+		t.Errorf("config.yaml mismatch, got:\n%s", string(content))
+	}
+}
+
+func TestTemplateManifestPromptInputEnds(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "yankrun.yaml", "variables:\n  - name: PORT\n    type: int\n")
+	writeFile(t, workDir, "config.yaml", "port: [[PORT]]\n")
+
+	// the input ends on an invalid answer that cannot be asked again
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--prompt")
+	cmd.Dir = repoRoot(t)
+	cmd.Stdin = strings.NewReader("abc")
+	out, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), `PORT: must be an integer, got "abc"`) {
+		t.Fatalf("expected the run to fail on the invalid answer, got %v.\nFull output:\n%s", err, string(out))
+	}
+}
+
+func TestTemplateManifestSections(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "yankrun.yaml", `variables:
+  - name: USE_DB
+    type: bool
+    default: false
+sections:
+  - title: Database
+    ask_if: USE_DB
+    variables:
+      - name: DB_NAME
+        default: app
+      - name: DB_USER
+        required: true
+  - title: Cache
+    variables:
+      - name: CACHE
+        type: bool
+      - name: CACHE_TTL
+        type: int
+        default: 60
+        ask_if: CACHE
+`)
+	writeFile(t, workDir, "config.yaml", "[[#if USE_DB]]db: [[DB_NAME]] [[DB_USER]]\n[[/if]]cache: [[CACHE]] [[CACHE_TTL]]\n")
+
+	// DB_USER is required but not asked for, as its section is skipped
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--prompt")
+	cmd.Dir = repoRoot(t)
+	cmd.Stdin = strings.NewReader("\ny\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	for _, want := range []string{
+		"-- Database --",
+		"value=(default: app) (skipped: USE_DB)",
+		"value=(skipped: USE_DB)",
+		"Skipped Database (ask_if: USE_DB)",
+		"-- Cache --\nEnter value for CACHE (y/n): Enter value for CACHE_TTL (integer) [60]: ",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %q in the output.\nFull output:\n%s", want, string(out))
+		}
+	}
+	if strings.Contains(string(out), "Enter value for DB_") {
+		t.Errorf("the Database section should be skipped.\nFull output:\n%s", string(out))
+	}
+
+	content, _ := os.ReadFile(filepath.Join(workDir, "config.yaml"))
+	if string(content) != "cache: true 60\n" {
+		t.Errorf("config.yaml mismatch, got:\n%s", string(content))
+	}
+}
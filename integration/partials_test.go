@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplatePartials(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(workDir, "_partials"), 0755); err != nil {
+		t.Fatalf("Failed to create partials dir: %v", err)
+	}
+	writeFile(t, workDir, "_partials/license-header.txt", "// Copyright [[ORG]]\n")
+	writeFile(t, workDir, "main.go", "[[> license-header]]\npackage main\n")
+	writeFile(t, workDir, "util.go.tpl", "[[> _partials/license-header]]\npackage util\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: ORG, value: Acme}]`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--processTemplates")
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	if !strings.Contains(string(out), "matches=2") {
+		t.Errorf("expected ORG to be counted once per include.\nFull output:\n%s", string(out))
+	}
+
+	for name, want := range map[string]string{"main.go": "// Copyright Acme\npackage main\n", "util.go": "// Copyright Acme\npackage util\n"} {
+		content, _ := os.ReadFile(filepath.Join(workDir, name))
+		if string(content) != want {
+			t.Errorf("%s mismatch, got:\n%s", name, string(content))
+		}
+	}
+	// the tree is templated in place, so _partials is left alone
+	if content, _ := os.ReadFile(filepath.Join(workDir, "_partials/license-header.txt")); string(content) != "// Copyright [[ORG]]\n" {
+		t.Errorf("_partials should be kept in place, got:\n%s", string(content))
+	}
+}
+
+func TestTemplateKeepsUnrelatedPartials(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(workDir, "_partials"), 0755); err != nil {
+		t.Fatalf("Failed to create partials dir: %v", err)
+	}
+	writeFile(t, workDir, "_partials/_buttons.scss", "// [[NAME]] buttons\n.btn { color: red; }\n")
+	writeFile(t, workDir, "main.scss", "// [[NAME]]\n@import 'partials/buttons';\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: NAME, value: site}]`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath)
+	cmd.Dir = repoRoot(t)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	// nothing includes a partial, so _partials is templated like the rest of the tree
+	for name, want := range map[string]string{"main.scss": "// site\n@import 'partials/buttons';\n", "_partials/_buttons.scss": "// site buttons\n.btn { color: red; }\n"} {
+		content, err := os.ReadFile(filepath.Join(workDir, name))
+		if err != nil || string(content) != want {
+			t.Errorf("%s mismatch, got %v:\n%s", name, err, string(content))
+		}
+	}
+}
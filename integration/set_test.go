@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateSetPrecedence(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+	valsDir := t.TempDir()
+
+	writeFile(t, workDir, "yankrun.yaml", "variables:\n  - {name: A, default: manifest}\n  - {name: B, default: manifest}\n  - {name: C, default: manifest}\n  - {name: D, default: manifest}\n  - {name: E, default: manifest}\n  - {name: F, type: int}\n")
+	writeFile(t, workDir, "out.txt", "A=[[A]] B=[[B]] C=[[C]] D=[[D]] E=[[E]] F=[[F]]\n")
+	valsPath := writeFile(t, valsDir, "values.yaml", `variables: [{key: B, value: file}, {key: C, value: file}, {key: D, value: file}, {key: E, value: file}]`)
+	notes := writeFile(t, valsDir, "notes.txt", "from a file\n")
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath,
+		"--set-file", "E="+notes, "--set", "D=set", "--set", "E=a=b", "--set", "F=42", "--prompt")
+	cmd.Dir = repoRoot(t)
+	cmd.Env = append(os.Environ(), "YANKRUN_VAR_C=env", "YANKRUN_VAR_D=env", "YANKRUN_VAR_F=1")
+	// every question is answered with Enter except the last one
+	cmd.Stdin = strings.NewReader("\n\n\n\n\n7\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+
+	content, _ := os.ReadFile(filepath.Join(workDir, "out.txt"))
+	if string(content) != "A=manifest B=file C=env D=set E=a=b F=7\n" {
+		t.Errorf("out.txt mismatch, got:\n%s", string(content))
+	}
+}
+
+func TestTemplateSetFile(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "LICENSE", "[[LICENSE_TEXT]]\n")
+	license := writeFile(t, t.TempDir(), "license.txt", "MIT License\n\nCopyright (c) acme\n")
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--set-file", "LICENSE_TEXT="+license)
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+	content, _ := os.ReadFile(filepath.Join(workDir, "LICENSE"))
+	if string(content) != "MIT License\n\nCopyright (c) acme\n" {
+		t.Errorf("LICENSE mismatch, got:\n%s", string(content))
+	}
+}
+
+func TestTemplateSetErrors(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+	writeFile(t, workDir, "yankrun.yaml", "variables:\n  - {name: PORT, type: int}\n")
+	writeFile(t, workDir, "out.txt", "[[PORT]]\n")
+
+	tests := []struct {
+		args []string
+		env  []string
+		want string
+	}{
+		{[]string{"--set", "PORT"}, nil, `--set "PORT": expected KEY=VALUE`},
+		{[]string{"--set", "=1"}, nil, `--set "=1": expected KEY=VALUE`},
+		{[]string{"--set-file", "PORT=" + filepath.Join(workDir, "missing")}, nil, "--set-file PORT:"},
+		{[]string{"--set", "PORT=http"}, nil, `PORT: must be an integer, got "http"`},
+		{nil, []string{"YANKRUN_VAR_PORT=http"}, `PORT: must be an integer, got "http"`},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(bin, append([]string{"template", "--dir", workDir}, tt.args...)...)
+		cmd.Dir = repoRoot(t)
+		cmd.Env = append(os.Environ(), tt.env...)
+		out, err := cmd.CombinedOutput()
+		if err == nil || !strings.Contains(string(out), tt.want) {
+			t.Errorf("%v %v: expected an error containing %q, got %v.\nFull output:\n%s", tt.args, tt.env, tt.want, err, string(out))
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestTemplateSuffixes(t *testing.T) {
+	bin := buildBinary(t)
+	workDir := t.TempDir()
+
+	writeFile(t, workDir, "main.go.tmpl", "package [[APP_NAME]]\n")
+	writeFile(t, workDir, "config.j2.yaml", "name: [[APP_NAME]]\n")
+	writeFile(t, workDir, "notes.txt", "[[APP_NAME]] notes\n")
+	valsPath := writeFile(t, t.TempDir(), "values.yaml", `variables: [{key: APP_NAME, value: demo}]`)
+
+	cmd := exec.Command(bin, "template", "--dir", workDir, "--input", valsPath, "--processTemplates",
+		"--templateSuffix", ".tmpl", "--templateSuffix", "j2", "--templateInfix")
+	cmd.Dir = repoRoot(t)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("template failed: %v\n%s", err, string(out))
+	}
+
+	for name, want := range map[string]string{"main.go": "package demo\n", "config.yaml": "name: demo\n", "notes.txt": "demo notes\n"} {
+		content, _ := os.ReadFile(filepath.Join(workDir, name))
+		if string(content) != want {
+			t.Errorf("%s mismatch, got:\n%s\nFull output:\n%s", name, string(content), string(out))
+		}
+	}
+	for _, name := range []string{"main.go.tmpl", "config.j2.yaml"} {
+		if _, err := os.Stat(filepath.Join(workDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should be renamed, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Package prompt asks questions on a terminal: free text, numbered menus, yes/no
+// questions, secrets that are not echoed and values over several lines. Answers are
+// checked and asked for again until they are valid.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Question is one question. Only Label is required.
+type Question struct {
+	Label     string   // printed before the answer, such as "Enter value for PORT"
+	Help      string   // printed on the line before the question
+	Hint      string   // expected answer, printed in parentheses after the label
+	Default   string   // printed in brackets; an empty answer keeps it
+	Choices   []string // printed as a numbered menu; the answer is a number or a choice
+	YesNo     bool     // the answer is y or n, returned as true or false
+	Secret    bool     // the answer is not echoed and the default is shown as ****
+	Multiline bool     // the answer ends with a line holding only "."
+	// Validate checks a non-empty answer and returns it in canonical form. The
+	// question is asked again while it returns an error.
+	Validate func(answer string) (string, error)
+}
+
+// Prompter asks questions on in and out.
+type Prompter struct {
+	r   *bufio.Reader
+	out io.Writer
+	in  *os.File // set when in is a terminal, to turn echo off for secrets
+}
+
+// New returns a Prompter reading answers from in and writing questions to out.
+func New(in io.Reader, out io.Writer) *Prompter {
+	p := &Prompter{r: bufio.NewReader(in), out: out}
+	if f, ok := in.(*os.File); ok && isTerminal(f) {
+		p.in = f
+	}
+	return p
+}
+
+// Ask asks q until the answer is valid. An empty answer returns "", leaving the default
+// to the caller. When the input ends before an answer is given, Ask returns io.EOF.
+func (p *Prompter) Ask(q Question) (string, error) {
+	if q.Help != "" {
+		fmt.Fprintln(p.out, q.Help)
+	}
+	for i, c := range q.Choices {
+		fmt.Fprintf(p.out, "  [%d] %s\n", i+1, c)
+	}
+	for {
+		fmt.Fprint(p.out, q.prompt())
+		answer, err := p.read(q)
+		if answer == "" {
+			return "", err
+		}
+		value, checkErr := q.check(answer)
+		if checkErr == nil {
+			return value, nil
+		}
+		if err != nil {
+			// the input ended, the question cannot be asked again
+			return "", checkErr
+		}
+		fmt.Fprintf(p.out, "  %v\n", checkErr)
+	}
+}
+
+// Confirm asks a yes/no question and returns def when the answer is empty or the input
+// has ended.
+func (p *Prompter) Confirm(label string, def bool) (bool, error) {
+	shown := "n"
+	if def {
+		shown = "y"
+	}
+	answer, err := p.Ask(Question{Label: label, YesNo: true, Default: shown})
+	if answer == "" {
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		return def, err
+	}
+	return answer == "true", nil
+}
+
+// Select shows options as a numbered menu and returns the index of the chosen one, or
+// def when the answer is empty or the input has ended.
+func (p *Prompter) Select(label string, options []string, def int) (int, error) {
+	q := Question{Label: label, Choices: options}
+	if def >= 0 && def < len(options) {
+		q.Default = strconv.Itoa(def + 1)
+	}
+	answer, err := p.Ask(q)
+	if answer == "" {
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		return def, err
+	}
+	for i, o := range options {
+		if o == answer {
+			return i, nil
+		}
+	}
+	return def, nil
+}
+
+func (q Question) prompt() string {
+	var sb strings.Builder
+	sb.WriteString(q.Label)
+	switch {
+	case q.Hint != "":
+		sb.WriteString(" (" + q.Hint + ")")
+	case q.YesNo:
+		sb.WriteString(" (y/n)")
+	case len(q.Choices) > 0:
+		fmt.Fprintf(&sb, " (1-%d)", len(q.Choices))
+	}
+	if q.Multiline {
+		sb.WriteString(", end with a line holding only .")
+	}
+	switch {
+	case q.Secret && q.Default != "":
+		sb.WriteString(" [****]")
+	case q.Default != "":
+		sb.WriteString(" [" + q.Default + "]")
+	}
+	sb.WriteString(": ")
+	if q.Multiline {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// check turns a non-empty answer into the value of q.
+func (q Question) check(answer string) (string, error) {
+	switch {
+	case q.YesNo:
+		b, ok := parseYesNo(answer)
+		if !ok {
+			return "", fmt.Errorf("answer y or n, got %q", answer)
+		}
+		answer = strconv.FormatBool(b)
+	case len(q.Choices) > 0:
+		choice, ok := pick(q.Choices, answer)
+		if !ok {
+			return "", fmt.Errorf("answer a number from 1 to %d or one of the choices, got %q", len(q.Choices), answer)
+		}
+		answer = choice
+	}
+	if q.Validate == nil {
+		return answer, nil
+	}
+	return q.Validate(answer)
+}
+
+// read reads an answer. Single-line answers are trimmed; multi-line answers keep their
+// lines as typed.
+func (p *Prompter) read(q Question) (string, error) {
+	if q.Secret && p.in != nil {
+		restore, ok := disableEcho(p.in)
+		if ok {
+			defer func() {
+				restore()
+				fmt.Fprintln(p.out)
+			}()
+		}
+	}
+	if !q.Multiline {
+		line, err := p.r.ReadString('\n')
+		return strings.TrimSpace(line), readErr(err)
+	}
+	var lines []string
+	for {
+		line, err := p.r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "." && err == nil {
+			return strings.Join(lines, "\n"), nil
+		}
+		if line != "" || err == nil {
+			lines = append(lines, line)
+		}
+		if err != nil {
+			return strings.Join(lines, "\n"), readErr(err)
+		}
+	}
+}
+
+// readErr reports a failed read; the end of the input is io.EOF.
+func readErr(err error) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return err
+	}
+	return fmt.Errorf("reading answer: %w", err)
+}
+
+// pick returns the choice named or numbered by answer. A choice written out wins over a
+// number, so choices that are numbers themselves can be typed.
+func pick(choices []string, answer string) (string, bool) {
+	for _, c := range choices {
+		if c == answer {
+			return c, true
+		}
+	}
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+		return choices[n-1], true
+	}
+	return "", false
+}
+
+func parseYesNo(answer string) (bool, bool) {
+	switch strings.ToLower(answer) {
+	case "y", "yes", "true", "on", "1":
+		return true, true
+	case "n", "no", "false", "off", "0":
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAsk(t *testing.T) {
+	port := func(v string) (string, error) {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("must be an integer 1-65535, got %q", v)
+		}
+		return v, nil
+	}
+	tests := []struct {
+		name    string
+		q       Question
+		input   string
+		want    string
+		wantErr error
+		output  []string
+	}{
+		{"answer", Question{Label: "Name"}, "  demo \n", "demo", nil, []string{"Name: "}},
+		{"empty answer keeps the default", Question{Label: "Name", Default: "demo"}, "\n", "", nil, []string{"Name [demo]: "}},
+		{"asked again until valid", Question{Label: "Port", Hint: "integer", Validate: port}, "abc\n0\n8080\n", "8080", nil,
+			[]string{`must be an integer 1-65535, got "abc"`, `got "0"`, "Port (integer): "}},
+		{"choice by number", Question{Label: "DB", Choices: []string{"postgres", "mysql"}}, "2\n", "mysql", nil, []string{"  [1] postgres\n  [2] mysql\n", "DB (1-2): "}},
+		{"choice by name", Question{Label: "DB", Choices: []string{"postgres", "mysql"}}, "postgres\n", "postgres", nil, nil},
+		{"not a choice", Question{Label: "DB", Choices: []string{"postgres", "mysql"}}, "3\nmysql\n", "mysql", nil, []string{"answer a number from 1 to 2"}},
+		{"numeric choices", Question{Label: "N", Choices: []string{"3", "1"}}, "1\n", "1", nil, nil},
+		{"yes/no", Question{Label: "Debug", YesNo: true}, "maybe\nY\n", "true", nil, []string{"Debug (y/n): ", "answer y or n"}},
+		{"secret default is masked", Question{Label: "Token", Default: "s3cret", Secret: true}, "\n", "", nil, []string{"Token [****]: "}},
+		{"multi-line", Question{Label: "Notes", Multiline: true}, "one\n\ntwo\n.\n", "one\n\ntwo", nil, []string{"end with a line holding only ."}},
+		{"multi-line until the end of the input", Question{Label: "Notes", Multiline: true}, "one\ntwo", "one\ntwo", nil, nil},
+		{"end of input", Question{Label: "Name"}, "", "", io.EOF, nil},
+		{"last answer without newline", Question{Label: "Name"}, "demo", "demo", nil, nil},
+		{"invalid last answer", Question{Label: "Port", Validate: port}, "abc", "", errors.New(`must be an integer 1-65535, got "abc"`), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := New(strings.NewReader(tt.input), &out).Ask(tt.q)
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if fmt.Sprint(err) != fmt.Sprint(tt.wantErr) {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			for _, want := range tt.output {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected %q in the output, got:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestConfirmAndSelect(t *testing.T) {
+	p := New(strings.NewReader("y\n\n2\n\n"), io.Discard)
+	if ok, err := p.Confirm("Continue?", false); !ok || err != nil {
+		t.Errorf("Confirm: expected true, got %v (%v)", ok, err)
+	}
+	if ok, err := p.Confirm("Continue?", true); !ok || err != nil {
+		t.Errorf("Confirm: expected the default, got %v (%v)", ok, err)
+	}
+	options := []string{"main", "develop"}
+	if idx, err := p.Select("Branch", options, 0); idx != 1 || err != nil {
+		t.Errorf("Select: expected 1, got %d (%v)", idx, err)
+	}
+	if idx, err := p.Select("Branch", options, -1); idx != -1 || err != nil {
+		t.Errorf("Select: expected the default, got %d (%v)", idx, err)
+	}
+	// the input has ended
+	if idx, err := p.Select("Branch", options, 0); idx != 0 || err != nil {
+		t.Errorf("Select: expected the default at the end of the input, got %d (%v)", idx, err)
+	}
+}
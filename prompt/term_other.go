@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+
+package prompt
+
+import "os"
+
+// Secrets are echoed where turning echo off is not supported.
+
+func isTerminal(f *os.File) bool { return false }
+
+func disableEcho(f *os.File) (restore func(), ok bool) { return nil, false }
@@ -0,0 +1,30 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package prompt
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func isTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetTermios(int(f.Fd()), getTermios)
+	return err == nil
+}
+
+// disableEcho stops f from echoing what is typed until restore is called.
+func disableEcho(f *os.File) (restore func(), ok bool) {
+	fd := int(f.Fd())
+	state, err := unix.IoctlGetTermios(fd, getTermios)
+	if err != nil {
+		return nil, false
+	}
+	noEcho := *state
+	noEcho.Lflag &^= unix.ECHO
+	noEcho.Lflag |= unix.ICANON | unix.ISIG
+	if err := unix.IoctlSetTermios(fd, setTermios, &noEcho); err != nil {
+		return nil, false
+	}
+	return func() { _ = unix.IoctlSetTermios(fd, setTermios, state) }, true
+}
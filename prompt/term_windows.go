@@ -0,0 +1,26 @@
+package prompt
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(f.Fd()), &mode) == nil
+}
+
+// disableEcho stops f from echoing what is typed until restore is called.
+func disableEcho(f *os.File) (restore func(), ok bool) {
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return nil, false
+	}
+	noEcho := mode&^windows.ENABLE_ECHO_INPUT | windows.ENABLE_LINE_INPUT | windows.ENABLE_PROCESSED_INPUT
+	if err := windows.SetConsoleMode(h, noEcho); err != nil {
+		return nil, false
+	}
+	return func() { _ = windows.SetConsoleMode(h, mode) }, true
+}
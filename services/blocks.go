@@ -0,0 +1,640 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+// Block tags use the configured delimiters:
+//
+//	[[#if USE_DB]] ... [[else]] ... [[/if]]
+//	[[#each SERVICES as S]] ... [[S.name]] ... [[@index]] ... [[/each]]
+//	[[raw]] ... copied as is, tags included ... [[/raw]]
+//	[[> license-header]] includes a partial, see partials.go
+//
+// A backslash before a tag, as in \[[x]], writes the delimiter literally. Other
+// backslashes, such as the one in the regex \[[a-z]+\], are left alone.
+//
+// A block tag that sits alone on its line removes the whole line from the output,
+// so templates can keep tags on their own lines without leaving blank lines behind.
+
+// TemplateError reports a problem in a templated file at a given position.
+type TemplateError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *TemplateError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+type templateToken struct {
+	text  string // raw text; for tags this includes the delimiters
+	inner string // tag content between the delimiters
+	isTag bool
+	line  int
+	col   int
+	// bytes trimmed from the start/end of a text token by standalone block tags
+	cutStart int
+	cutEnd   int
+}
+
+type templateNode interface{}
+
+type textNode struct {
+	text string
+}
+
+type placeholderNode struct {
+	raw   string // full tag, written back verbatim when there is no value
+	inner string
+	expr  *placeholderExpr
+	err   error // syntax error in inner, reported only once the placeholder has a value
+	line  int
+	col   int
+}
+
+type ifNode struct {
+	cond      string
+	then, els []templateNode
+	line, col int
+}
+
+type eachNode struct {
+	list      string
+	alias     string
+	body      []templateNode
+	line, col int
+}
+
+// partialNode is a [[> name]] include. file and body are filled in when the partial
+// is resolved; an unresolved include is written back verbatim.
+type partialNode struct {
+	raw       string
+	name      string
+	file      string
+	body      []templateNode
+	line, col int
+}
+
+// renderScope resolves variable names while rendering. Each loop iteration gets a
+// child scope holding the loop variable and the @index/@first/@last helpers.
+type renderScope struct {
+	values map[string]string
+	lists  map[string][]domain.ListItem
+	parent *renderScope
+
+	partials *partialSet // set on the root scope when [[> name]] includes can be resolved
+}
+
+// newRenderScope builds the root scope from the replacement values
+func newRenderScope(replacements domain.InputReplacement) *renderScope {
+	scope := &renderScope{values: map[string]string{}, lists: map[string][]domain.ListItem{}}
+	for _, r := range replacements.Variables {
+		if r.List != nil {
+			scope.lists[r.Key] = r.List
+			continue
+		}
+		scope.values[r.Key] = r.Value
+	}
+	return scope
+}
+
+func (s *renderScope) lookup(key string) (string, bool) {
+	for sc := s; sc != nil; sc = sc.parent {
+		if v, ok := sc.values[key]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// list returns the items of a list variable. Scalar values are split on commas so
+// lists can also be entered at an interactive prompt.
+func (s *renderScope) list(key string) []domain.ListItem {
+	for sc := s; sc != nil; sc = sc.parent {
+		if l, ok := sc.lists[key]; ok {
+			return l
+		}
+	}
+	v, ok := s.lookup(key)
+	if !ok || strings.TrimSpace(v) == "" {
+		return nil
+	}
+	var items []domain.ListItem
+	for _, part := range strings.Split(v, ",") {
+		items = append(items, domain.ListItem{Value: strings.TrimSpace(part)})
+	}
+	return items
+}
+
+// iteration returns the child scope for item i of a loop over n items bound to alias.
+func (s *renderScope) iteration(alias string, item domain.ListItem, i, n int) *renderScope {
+	values := map[string]string{
+		"@index": strconv.Itoa(i),
+		"@first": strconv.FormatBool(i == 0),
+		"@last":  strconv.FormatBool(i == n-1),
+	}
+	if item.Fields == nil {
+		values[alias] = item.Value
+	}
+	for k, v := range item.Fields {
+		values[alias+"."+k] = v
+	}
+	return &renderScope{values: values, parent: s}
+}
+
+// tokenizeTemplate splits text into text and tag tokens. Like the original
+// placeholder regex, a tag is the shortest startDelim...endDelim run on a single line.
+// A backslash before a tag makes it literal text ("\\" before it is a literal
+// backslash), and everything between raw tags is a single text token.
+func tokenizeTemplate(text, startDelim, endDelim string) []templateToken {
+	var tokens []templateToken
+	line, col := 1, 1
+	advance := func(s string) {
+		for _, r := range s {
+			if r == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+		}
+	}
+	emitText := func(s string) {
+		if s != "" {
+			tokens = append(tokens, templateToken{text: s, line: line, col: col})
+			advance(s)
+		}
+	}
+
+	pending := 0 // start of text not yet emitted
+	for search := 0; search < len(text); {
+		idx := strings.Index(text[search:], startDelim)
+		if idx == -1 {
+			break
+		}
+		start := search + idx
+		rest := text[start+len(startDelim):]
+		end := strings.Index(rest, endDelim)
+		if end == -1 {
+			break
+		}
+		if strings.Contains(rest[:end], "\n") {
+			search = start + 1
+			continue
+		}
+		if start > pending && text[start-1] == '\\' {
+			// a tag follows, drop one backslash: "\\[[" is a literal "[[" and "\\\\[[" a
+			// backslash before a tag. A backslash before anything else, such as the
+			// regex \[[a-z]+\], is left alone.
+			emitText(text[pending : start-1])
+			advance(`\`)
+			pending = start
+			if start-1 == 0 || text[start-2] != '\\' {
+				search = start + len(startDelim)
+				continue
+			}
+		}
+		emitText(text[pending:start])
+		tagEnd := start + len(startDelim) + end + len(endDelim)
+		tokens = append(tokens, templateToken{text: text[start:tagEnd], inner: rest[:end], isTag: true, line: line, col: col})
+		advance(text[start:tagEnd])
+		pending = tagEnd
+		search = tagEnd
+
+		if blockTagKind(rest[:end]) == "raw" {
+			// the content of a raw region is not scanned for tags
+			closeTag := startDelim + "/raw" + endDelim
+			closeIdx := strings.Index(text[tagEnd:], closeTag)
+			if closeIdx == -1 {
+				continue
+			}
+			emitText(text[tagEnd : tagEnd+closeIdx])
+			closeStart := tagEnd + closeIdx
+			tokens = append(tokens, templateToken{text: closeTag, inner: "/raw", isTag: true, line: line, col: col})
+			advance(closeTag)
+			pending = closeStart + len(closeTag)
+			search = pending
+		}
+	}
+	emitText(text[pending:])
+	markStandaloneTags(tokens)
+	return tokens
+}
+
+// blockTagKind returns the kind of block tag ("if", "else", "/if", "each", "/each",
+// "raw", "/raw"), "partial" for includes or "" for placeholders.
+func blockTagKind(inner string) string {
+	inner = strings.TrimSpace(inner)
+	switch {
+	case inner == "#if" || strings.HasPrefix(inner, "#if "):
+		return "if"
+	case inner == "else":
+		return "else"
+	case inner == "/if":
+		return "/if"
+	case inner == "#each" || strings.HasPrefix(inner, "#each "):
+		return "each"
+	case inner == "/each":
+		return "/each"
+	case inner == "raw":
+		return "raw"
+	case inner == "/raw":
+		return "/raw"
+	case strings.HasPrefix(inner, ">"):
+		return "partial"
+	}
+	return ""
+}
+
+// blockOpener maps closing tags to the opening tag they belong to.
+var blockOpener = map[string]string{"else": "#if", "/if": "#if", "/each": "#each", "/raw": "raw"}
+
+// markStandaloneTags trims the surrounding whitespace and line break of block tags
+// that are the only thing on their line.
+func markStandaloneTags(tokens []templateToken) {
+	for i, tok := range tokens {
+		if !tok.isTag || blockTagKind(tok.inner) == "" {
+			continue
+		}
+		// text between the previous line break and the tag must be blank
+		before := 0
+		if i > 0 {
+			prev := tokens[i-1]
+			if prev.isTag {
+				continue
+			}
+			nl := strings.LastIndexByte(prev.text, '\n')
+			if nl == -1 && i-1 > 0 {
+				continue
+			}
+			tail := prev.text[nl+1:]
+			if strings.TrimSpace(tail) != "" {
+				continue
+			}
+			before = len(tail)
+		}
+		// text between the tag and the next line break must be blank
+		after := 0
+		if i+1 < len(tokens) {
+			next := tokens[i+1]
+			if next.isTag {
+				continue
+			}
+			nl := strings.IndexByte(next.text, '\n')
+			head := next.text
+			if nl != -1 {
+				head = next.text[:nl+1]
+			} else if i+2 < len(tokens) {
+				continue
+			}
+			if strings.TrimSpace(head) != "" {
+				continue
+			}
+			after = len(head)
+		}
+		if i > 0 {
+			tokens[i-1].cutEnd = before
+		}
+		if i+1 < len(tokens) {
+			tokens[i+1].cutStart = after
+		}
+	}
+}
+
+// parseTemplate builds the node tree for text. file is only used in error messages.
+func parseTemplate(file, text, startDelim, endDelim string) ([]templateNode, error) {
+	p := &templateParser{file: file, tokens: tokenizeTemplate(text, startDelim, endDelim), startDelim: startDelim, endDelim: endDelim}
+	nodes, closer, err := p.parseUntil()
+	if err != nil {
+		return nil, err
+	}
+	if closer != nil {
+		opener := blockOpener[blockTagKind(closer.inner)]
+		return nil, p.errorAt(*closer, fmt.Sprintf("unexpected %s without a matching %s%s%s", closer.text, startDelim, opener, endDelim))
+	}
+	return nodes, nil
+}
+
+type templateParser struct {
+	file       string
+	tokens     []templateToken
+	pos        int
+	startDelim string
+	endDelim   string
+}
+
+func (p *templateParser) errorAt(tok templateToken, msg string) error {
+	return &TemplateError{File: p.file, Line: tok.line, Column: tok.col, Msg: msg}
+}
+
+// parseUntil parses nodes until a closing or else tag, which is returned unconsumed
+// to the caller, or until the end of input (closer is nil).
+func (p *templateParser) parseUntil() ([]templateNode, *templateToken, error) {
+	var nodes []templateNode
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		if !tok.isTag {
+			text := tok.text
+			if tok.cutStart+tok.cutEnd >= len(text) {
+				text = ""
+			} else {
+				text = text[tok.cutStart : len(text)-tok.cutEnd]
+			}
+			if text != "" {
+				nodes = append(nodes, textNode{text: text})
+			}
+			p.pos++
+			continue
+		}
+
+		switch blockTagKind(tok.inner) {
+		case "":
+			if isLiteralTag(tok.inner) {
+				nodes = append(nodes, textNode{text: tok.text})
+				p.pos++
+				continue
+			}
+			expr, err := parsePlaceholderExpr(tok.inner)
+			if se, ok := err.(*placeholderSyntaxError); ok {
+				col := tok.col + utf8.RuneCountInString(p.startDelim) + utf8.RuneCountInString(tok.inner[:se.offset])
+				err = &TemplateError{File: p.file, Line: tok.line, Column: col, Msg: fmt.Sprintf("invalid placeholder '%s': %s", tok.inner, se.msg)}
+			}
+			nodes = append(nodes, placeholderNode{raw: tok.text, inner: tok.inner, expr: expr, err: err, line: tok.line, col: tok.col})
+			p.pos++
+		case "if":
+			n, err := p.parseIf(tok)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, n)
+		case "each":
+			n, err := p.parseEach(tok)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, n)
+		case "partial":
+			name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tok.inner), ">"))
+			if name == "" {
+				return nil, nil, p.errorAt(tok, "missing partial name in "+tok.text)
+			}
+			nodes = append(nodes, &partialNode{raw: tok.text, name: name, line: tok.line, col: tok.col})
+			p.pos++
+		case "raw":
+			// the tokenizer emits the content as plain text followed by the closing tag
+			p.pos++
+			body, closer, err := p.parseUntil()
+			if err != nil {
+				return nil, nil, err
+			}
+			if closer == nil || blockTagKind(closer.inner) != "/raw" {
+				return nil, nil, p.errorAt(tok, fmt.Sprintf("unclosed %s: missing %s/raw%s", tok.text, p.startDelim, p.endDelim))
+			}
+			p.pos++
+			nodes = append(nodes, body...)
+		default:
+			return nodes, &p.tokens[p.pos], nil
+		}
+	}
+	return nodes, nil, nil
+}
+
+func (p *templateParser) parseIf(open templateToken) (templateNode, error) {
+	cond := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(open.inner), "#if"))
+	if cond == "" {
+		return nil, p.errorAt(open, "missing condition in "+open.text)
+	}
+	if _, err := parseCondition(cond); err != nil {
+		return nil, p.errorAt(open, err.Error())
+	}
+	n := &ifNode{cond: cond, line: open.line, col: open.col}
+	p.pos++
+
+	var err error
+	var closer *templateToken
+	n.then, closer, err = p.parseUntil()
+	if err != nil {
+		return nil, err
+	}
+	if closer != nil && blockTagKind(closer.inner) == "else" {
+		p.pos++
+		n.els, closer, err = p.parseUntil()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if closer == nil {
+		return nil, p.errorAt(open, fmt.Sprintf("unclosed %s: missing %s/if%s", open.text, p.startDelim, p.endDelim))
+	}
+	if blockTagKind(closer.inner) != "/if" {
+		return nil, p.errorAt(*closer, fmt.Sprintf("unexpected %s inside %s opened at line %d", closer.text, open.text, open.line))
+	}
+	p.pos++
+	return n, nil
+}
+
+func (p *templateParser) parseEach(open templateToken) (templateNode, error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(open.inner), "#each"))
+	if len(fields) != 3 || fields[1] != "as" {
+		return nil, p.errorAt(open, fmt.Sprintf("invalid %s: expected %s#each LIST as NAME%s", open.text, p.startDelim, p.endDelim))
+	}
+	n := &eachNode{list: fields[0], alias: fields[2], line: open.line, col: open.col}
+	p.pos++
+
+	var err error
+	var closer *templateToken
+	n.body, closer, err = p.parseUntil()
+	if err != nil {
+		return nil, err
+	}
+	if closer == nil {
+		return nil, p.errorAt(open, fmt.Sprintf("unclosed %s: missing %s/each%s", open.text, p.startDelim, p.endDelim))
+	}
+	if blockTagKind(closer.inner) != "/each" {
+		return nil, p.errorAt(*closer, fmt.Sprintf("unexpected %s inside %s opened at line %d", closer.text, open.text, open.line))
+	}
+	p.pos++
+	return n, nil
+}
+
+// renderText evaluates block tags and substitutes placeholders in text, returning the
+// result and the number of placeholders and blocks that were resolved. Placeholders
+// without a value are left untouched. file is only used in error messages.
+func (fr *FileReplacer) renderText(file, text string, scope *renderScope, startDelim, endDelim string) (string, int, error) {
+	// fast path: nothing that looks like a tag
+	if !strings.Contains(text, startDelim) {
+		return text, 0, nil
+	}
+	nodes, err := parseTemplate(file, text, startDelim, endDelim)
+	if err != nil {
+		return "", 0, err
+	}
+	if scope.partials != nil {
+		if err := scope.partials.expand(file, nodes, startDelim, endDelim, nil); err != nil {
+			return "", 0, err
+		}
+	}
+	var sb strings.Builder
+	n, err := fr.renderNodes(&sb, file, nodes, scope)
+	if err != nil {
+		return "", 0, err
+	}
+	return sb.String(), n, nil
+}
+
+func (fr *FileReplacer) renderNodes(sb *strings.Builder, file string, nodes []templateNode, scope *renderScope) (int, error) {
+	numReplacements := 0
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case textNode:
+			sb.WriteString(n.text)
+		case placeholderNode:
+			value, ok, err := fr.resolvePlaceholder(n, scope)
+			if err != nil {
+				if te, ok := err.(*TemplateError); ok {
+					return 0, te
+				}
+				return 0, &TemplateError{File: file, Line: n.line, Column: n.col, Msg: err.Error()}
+			}
+			if !ok {
+				sb.WriteString(n.raw)
+				continue
+			}
+			sb.WriteString(value)
+			numReplacements++
+		case *ifNode:
+			ok, err := EvalCondition(n.cond, scope.lookup)
+			if err != nil {
+				return 0, &TemplateError{File: file, Line: n.line, Column: n.col, Msg: err.Error()}
+			}
+			branch := n.els
+			if ok {
+				branch = n.then
+			}
+			count, err := fr.renderNodes(sb, file, branch, scope)
+			if err != nil {
+				return 0, err
+			}
+			numReplacements += count + 1
+		case *eachNode:
+			items := scope.list(n.list)
+			for i, item := range items {
+				count, err := fr.renderNodes(sb, file, n.body, scope.iteration(n.alias, item, i, len(items)))
+				if err != nil {
+					return 0, err
+				}
+				numReplacements += count
+			}
+			numReplacements++
+		case *partialNode:
+			if n.body == nil && n.file == "" {
+				sb.WriteString(n.raw)
+				continue
+			}
+			count, err := fr.renderNodes(sb, n.file, n.body, scope)
+			if err != nil {
+				return 0, err
+			}
+			numReplacements += count + 1
+		}
+	}
+	return numReplacements, nil
+}
+
+// resolvePlaceholder returns the transformed value for a placeholder, or false when
+// it has no value. A required placeholder without a value, a syntax error in a
+// placeholder that has a value and a failing transformation are errors.
+func (fr *FileReplacer) resolvePlaceholder(n placeholderNode, scope *renderScope) (string, bool, error) {
+	key := n.expr.key
+
+	// Get the base value, falling back to the inline default
+	baseValue, ok := scope.lookup(key.name)
+	if !ok || baseValue == "" {
+		switch {
+		case key.hasDefault:
+			baseValue, ok = key.def, true
+		case key.required:
+			return "", false, fmt.Errorf("required value %s is not set", key.name)
+		}
+	}
+	if !ok {
+		// If no replacement value is found, skip this placeholder
+		return "", false, nil
+	}
+	if n.err != nil {
+		return "", false, n.err
+	}
+
+	// Apply transformations
+	finalValue, err := fr.applyTransformations(baseValue, n.expr.transforms)
+	if err != nil {
+		return "", false, fmt.Errorf("placeholder '%s': %w", n.inner, err)
+	}
+	return finalValue, true, nil
+}
+
+// collectTemplateRefs records the variables referenced by placeholders, block conditions
+// and loops in file. Names bound by an enclosing loop (and the @ helpers) are not counted.
+func (fr *FileReplacer) collectTemplateRefs(file string, nodes []templateNode, bound map[string]bool, result map[string]*PlaceholderInfo) {
+	isBound := func(key string) bool {
+		if strings.HasPrefix(key, "@") {
+			return true
+		}
+		if i := strings.IndexByte(key, '.'); i != -1 {
+			key = key[:i]
+		}
+		return bound[key]
+	}
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case placeholderNode:
+			if n.err != nil {
+				// Log error but keep counting the key, the error only matters once it has a value
+				fmt.Printf("Warning: %v\n", n.err)
+			}
+			key := n.expr.key
+			if isBound(key.name) {
+				continue
+			}
+			info := addPlaceholderRef(result, key.name)
+			if key.hasDefault && !info.HasDefault {
+				info.Default, info.HasDefault = key.def, true
+			}
+			if key.required {
+				info.Required = true
+				info.RequiredAt = append(info.RequiredAt, fmt.Sprintf("%s:%d", file, n.line))
+			}
+		case *ifNode:
+			refs, _ := ConditionRefs(n.cond)
+			for _, ref := range refs {
+				if !isBound(ref) {
+					addPlaceholderRef(result, ref)
+				}
+			}
+			fr.collectTemplateRefs(file, n.then, bound, result)
+			fr.collectTemplateRefs(file, n.els, bound, result)
+		case *eachNode:
+			if !isBound(n.list) {
+				addPlaceholderRef(result, n.list)
+			}
+			inner := map[string]bool{n.alias: true}
+			for k := range bound {
+				inner[k] = true
+			}
+			fr.collectTemplateRefs(file, n.body, inner, result)
+		case *partialNode:
+			fr.collectTemplateRefs(n.file, n.body, bound, result)
+		}
+	}
+}
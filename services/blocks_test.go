@@ -0,0 +1,398 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func TestRenderConditionalBlocks(t *testing.T) {
+	values := &renderScope{values: map[string]string{
+		"USE_DB":  "true",
+		"NO_DB":   "false",
+		"DB":      "postgres",
+		"APP":     "demo",
+		"COUNT":   "0",
+		"EMPTY":   "",
+		"CI":      "github",
+		"FEATURE": "yes",
+	}}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"true branch", "a[[#if USE_DB]]b[[/if]]c", "abc"},
+		{"false branch", "a[[#if NO_DB]]b[[/if]]c", "ac"},
+		{"else branch", "[[#if NO_DB]]yes[[else]]no[[/if]]", "no"},
+		{"missing variable is false", "[[#if UNKNOWN]]yes[[else]]no[[/if]]", "no"},
+		{"zero is false", "[[#if COUNT]]yes[[else]]no[[/if]]", "no"},
+		{"empty is false", "[[#if EMPTY]]yes[[else]]no[[/if]]", "no"},
+		{"not", "[[#if not NO_DB]]yes[[/if]]", "yes"},
+		{"eq", `[[#if eq DB "postgres"]]pg[[/if]]`, "pg"},
+		{"ne", `[[#if ne DB 'mysql']]not mysql[[/if]]`, "not mysql"},
+		{"and", `[[#if and USE_DB (eq DB "postgres") FEATURE]]ok[[/if]]`, "ok"},
+		{"or", `[[#if or NO_DB (eq CI "gitlab")]]yes[[else]]no[[/if]]`, "no"},
+		{"nested", "[[#if USE_DB]]db:[[#if eq DB \"postgres\"]]pg[[else]]other[[/if]][[/if]]", "db:pg"},
+		{"placeholders inside blocks", "[[#if USE_DB]]name=[[APP:toUpperCase]][[/if]]", "name=DEMO"},
+		{"placeholder without value kept", "[[#if USE_DB]][[OTHER]][[/if]]", "[[OTHER]]"},
+		{
+			"standalone tags remove their lines",
+			"services:\n  [[#if USE_DB]]\n  db:\n    image: [[DB]]\n  [[/if]]\n  [[#if NO_DB]]\n  cache: {}\n  [[else]]\n  app: {}\n  [[/if]]\nend\n",
+			"services:\n  db:\n    image: postgres\n  app: {}\nend\n",
+		},
+		{"inline tags keep surrounding text", "x [[#if USE_DB]]y[[/if]] z\n", "x y z\n"},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := fr.renderText("test.txt", tt.input, values, "[[", "]]")
+			if err != nil {
+				t.Fatalf("renderText failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderConditionalBlockErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"unclosed if", "line1\n[[#if A]]\nbody\n", "config.yaml:2:1: unclosed [[#if A]]"},
+		{"stray close", "ok\n  [[/if]]\n", "config.yaml:2:3: unexpected [[/if]]"},
+		{"stray else", "[[else]]", "config.yaml:1:1: unexpected [[else]]"},
+		{"missing condition", "[[#if]]x[[/if]]", "config.yaml:1:1: missing condition"},
+		{"bad condition", "\n\n[[#if eq A]]x[[/if]]", "config.yaml:3:1: eq expects 2 operand(s)"},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := fr.renderText("config.yaml", tt.input, &renderScope{}, "[[", "]]")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestConditionalBlocksInDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	compose := "services:\n  app:\n    image: [[APP_NAME]]\n[[#if USE_DB]]\n  db:\n    image: postgres\n[[/if]]\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	tpl := "[[#if USE_DB]]DATABASE_URL=postgres://localhost[[else]]DATABASE_URL=[[/if]]\n"
+	if err := os.WriteFile(filepath.Join(tempDir, ".env.tpl"), []byte(tpl), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	counts, err := replacer.AnalyzeDir(tempDir, nil, "3 mb", "[[", "]]", false)
+	if err != nil {
+		t.Fatalf("AnalyzeDir failed: %v", err)
+	}
+	if counts["USE_DB"] != 2 || counts["APP_NAME"] != 1 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+	for key := range counts {
+		if strings.HasPrefix(key, "#") || strings.HasPrefix(key, "/") || key == "else" {
+			t.Errorf("block tag %q should not be reported as a placeholder", key)
+		}
+	}
+
+	replacements := domain.InputReplacement{
+		Variables: []domain.Replacement{
+			{Key: "APP_NAME", Value: "demo"},
+			{Key: "USE_DB", Value: "false"},
+		},
+	}
+	if err := replacer.ReplaceInDir(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+	if err := replacer.ProcessTemplateFiles(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ProcessTemplateFiles failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(tempDir, "docker-compose.yml"))
+	if string(got) != "services:\n  app:\n    image: demo\n" {
+		t.Errorf("docker-compose.yml mismatch, got %q", string(got))
+	}
+	got, _ = os.ReadFile(filepath.Join(tempDir, ".env"))
+	if string(got) != "DATABASE_URL=\n" {
+		t.Errorf(".env mismatch, got %q", string(got))
+	}
+}
+
+func TestRenderEachBlocks(t *testing.T) {
+	scope := &renderScope{
+		values: map[string]string{"APP": "demo", "CSV": "a, b ,c", "EMPTY": ""},
+		lists: map[string][]domain.ListItem{
+			"NAMES": {{Value: "x"}, {Value: "y"}, {Value: "z"}},
+			"SERVICES": {
+				{Fields: map[string]string{"name": "api", "port": "8080"}},
+				{Fields: map[string]string{"name": "worker", "port": "9090"}},
+			},
+			"NONE": {},
+		},
+	}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"scalar items", "[[#each NAMES as N]][[N]];[[/each]]", "x;y;z;"},
+		{"map items", "[[#each SERVICES as S]][[S.name]]=[[S.port]] [[/each]]", "api=8080 worker=9090 "},
+		{"transforms on items", "[[#each SERVICES as S]][[S.name:toUpperCase]][[/each]]", "APIWORKER"},
+		{"index helper", "[[#each NAMES as N]][[@index]][[N]] [[/each]]", "0x 1y 2z "},
+		{"first and last helpers", "[[#each NAMES as N]][[#if not @first]], [[/if]][[N]][[#if @last]].[[/if]][[/each]]", "x, y, z."},
+		{"outer variables visible", "[[#each NAMES as N]][[APP]]-[[N]] [[/each]]", "demo-x demo-y demo-z "},
+		{"nested loops", "[[#each SERVICES as S]][[#each NAMES as N]][[S.name]][[N]] [[/each]][[/each]]", "apix apiy apiz workerx workery workerz "},
+		{"comma separated scalar", "[[#each CSV as C]]<[[C]]>[[/each]]", "<a><b><c>"},
+		{"empty list", "a[[#each NONE as N]][[N]][[/each]]b", "ab"},
+		{"empty scalar", "a[[#each EMPTY as N]][[N]][[/each]]b", "ab"},
+		{"missing list", "a[[#each MISSING as N]][[N]][[/each]]b", "ab"},
+		{
+			"standalone tags remove their lines",
+			"services:\n[[#each SERVICES as S]]\n  [[S.name]]:\n    port: [[S.port]]\n[[/each]]\nend\n",
+			"services:\n  api:\n    port: 8080\n  worker:\n    port: 9090\nend\n",
+		},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := fr.renderText("test.txt", tt.input, scope, "[[", "]]")
+			if err != nil {
+				t.Fatalf("renderText failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderEachBlockErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"unclosed each", "[[#each A as X]]\nbody\n", "main.go:1:1: unclosed [[#each A as X]]"},
+		{"missing alias", "[[#each A]]x[[/each]]", "main.go:1:1: invalid [[#each A]]"},
+		{"stray close", "x\n[[/each]]", "main.go:2:1: unexpected [[/each]] without a matching [[#each]]"},
+		{"mismatched close", "[[#each A as X]]x[[/if]]", "main.go:1:18: unexpected [[/if]] inside [[#each A as X]]"},
+		{"else inside each", "[[#each A as X]]x[[else]]y[[/each]]", "unexpected [[else]] inside"},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := fr.renderText("main.go", tt.input, &renderScope{}, "[[", "]]")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestEachBlocksInDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "[[#each SERVICES as S]]\n[[S.name]]: [[S.port]] # [[APP]] [[@index]]\n[[/each]]\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "services.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	counts, err := replacer.AnalyzeDir(tempDir, nil, "3 mb", "[[", "]]", false)
+	if err != nil {
+		t.Fatalf("AnalyzeDir failed: %v", err)
+	}
+	if len(counts) != 2 || counts["SERVICES"] != 1 || counts["APP"] != 1 {
+		t.Errorf("expected only SERVICES and APP to be reported, got %v", counts)
+	}
+
+	replacements := domain.InputReplacement{
+		Variables: []domain.Replacement{
+			{Key: "APP", Value: "demo"},
+			{Key: "SERVICES", List: []domain.ListItem{
+				{Fields: map[string]string{"name": "api", "port": "8080"}},
+				{Fields: map[string]string{"name": "web", "port": "3000"}},
+			}},
+		},
+	}
+	if err := replacer.ReplaceInDir(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(tempDir, "services.yaml"))
+	if string(got) != "api: 8080 # demo 0\nweb: 3000 # demo 1\n" {
+		t.Errorf("services.yaml mismatch, got %q", string(got))
+	}
+}
+
+func TestRenderDefaultsAndRequired(t *testing.T) {
+	scope := &renderScope{values: map[string]string{"PORT": "9090", "NAME": "demo", "EMPTY": ""}}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"value wins over default", "port=[[PORT|8080]]", "port=9090"},
+		{"default when unset", "host=[[HOST|localhost]]", "host=localhost"},
+		{"default when empty", "x=[[EMPTY|fallback]]", "x=fallback"},
+		{"empty default", "x=[[HOST|]]", "x="},
+		{"transforms apply to defaults", "[[HOST|local host:toUpperCase:gsub( ,-)]]", "LOCAL-HOST"},
+		{"required with value", "[[NAME!]]", "demo"},
+		{"shell or is not a default", `if [[ -n "$A" || -z "$B" ]]; then`, `if [[ -n "$A" || -z "$B" ]]; then`},
+		{"shell not is not required", `[[ ! -f x ]] || [[ "$A" != "b" ]]`, `[[ ! -f x ]] || [[ "$A" != "b" ]]`},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := fr.renderText("test.txt", tt.input, scope, "[[", "]]")
+			if err != nil {
+				t.Fatalf("renderText failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	_, _, err := fr.renderText("app.env", "a\nURL=[[API_URL!]]\n", scope, "[[", "]]")
+	if err == nil || err.Error() != "app.env:2:5: required value API_URL is not set" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestAnalyzeDefaultsAndRequired(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("[[PORT|8080]] [[PORT]]\n[[API_URL!]]\nif [[ -n \"$A\" || -z \"$B\" ]]; then\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	infos, err := replacer.AnalyzePlaceholders(tempDir, nil, "3 mb", "[[", "]]", false)
+	if err != nil {
+		t.Fatalf("AnalyzePlaceholders failed: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected PORT and API_URL, got %v", infos)
+	}
+	if p := infos["PORT"]; p.Count != 2 || !p.HasDefault || p.Default != "8080" || p.Required {
+		t.Errorf("unexpected PORT info %+v", p)
+	}
+	want := filepath.Join(tempDir, "a.txt") + ":2"
+	if a := infos["API_URL"]; !a.Required || len(a.RequiredAt) != 1 || a.RequiredAt[0] != want {
+		t.Errorf("unexpected API_URL info %+v", a)
+	}
+}
+
+func TestRenderEscapedDelimiters(t *testing.T) {
+	scope := &renderScope{values: map[string]string{"NAME": "demo"}}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"escaped tag", `\[[NAME]] [[NAME]]`, "[[NAME]] demo"},
+		{"escaped block tag", `\[[#if NAME]]`, "[[#if NAME]]"},
+		{"escaped backslash", `\\[[NAME]]`, `\demo`},
+		{"bash test", `if \[[ -f "$F" ]]; then echo [[NAME]]; fi`, `if [[ -f "$F" ]]; then echo demo; fi`},
+		{"raw region", "[[raw]][[NAME]] [[#if X]] \\[[[[/raw]] [[NAME]]", `[[NAME]] [[#if X]] \[[ demo`},
+		{"standalone raw tags", "a\n[[raw]]\n[[[servers]]]\nname = \"[[NAME]]\"\n[[/raw]]\nb [[NAME]]\n", "a\n[[[servers]]]\nname = \"[[NAME]]\"\nb demo\n"},
+		{"raw inside block", "[[#if NAME]][[raw]][[x]][[/raw]][[/if]]", "[[x]]"},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := fr.renderText("main.go", tt.input, scope, "[[", "]]")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	_, _, err := fr.renderText("main.go", "x\n[[raw]]\n[[NAME]]", scope, "[[", "]]")
+	if err == nil || err.Error() != "main.go:2:1: unclosed [[raw]]: missing [[/raw]]" {
+		t.Errorf("expected an unclosed raw error, got %v", err)
+	}
+	_, _, err = fr.renderText("main.go", "[[/raw]]", scope, "[[", "]]")
+	if err == nil || !strings.Contains(err.Error(), "unexpected [[/raw]] without a matching [[raw]]") {
+		t.Errorf("expected a stray raw close error, got %v", err)
+	}
+}
+
+func TestAnalyzeSkipsEscapedDelimiters(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "[[NAME]]\n\\[[ -n \"$X\" ]]\n[[raw]]\n[[[tables]]]\nx = [[OTHER]]\n[[/raw]]\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "a.sh"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	infos, err := replacer.AnalyzePlaceholders(tempDir, nil, "3 mb", "[[", "]]", false)
+	if err != nil {
+		t.Fatalf("AnalyzePlaceholders failed: %v", err)
+	}
+	if len(infos) != 1 || infos["NAME"] == nil {
+		t.Fatalf("expected only NAME, got %v", infos)
+	}
+
+	repl := domain.InputReplacement{Variables: []domain.Replacement{{Key: "NAME", Value: "demo"}}}
+	if err := replacer.ReplaceInDir(tempDir, repl, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(tempDir, "a.sh"))
+	if string(got) != "demo\n[[ -n \"$X\" ]]\n[[[tables]]]\nx = [[OTHER]]\n" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
+
+func TestReplaceKeepsBackslashesOutsideTags(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"check.py":  "import re\nPATTERN = re.compile(r\"\\[[a-z]+\\]\")\n",
+		"build.bat": "copy out C:\\build\\[[stage]\\bin\r\nset LOG=C:\\logs\\[[2024]\r\n",
+		"app.txt":   "name: [[NAME]] \\[[ -n x\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	repl := domain.InputReplacement{Variables: []domain.Replacement{{Key: "NAME", Value: "demo"}}}
+	if err := replacer.ReplaceInDir(tempDir, repl, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+	files["app.txt"] = "name: demo \\[[ -n x\n"
+	for name, want := range files {
+		got, _ := os.ReadFile(filepath.Join(tempDir, name))
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// splitWords breaks s into words for the case transformations. Any character that is
+// not a letter or digit separates words; within a run of letters and digits a word
+// starts at a lower-to-upper change ("myService"), at the last capital of an acronym
+// followed by lowercase ("HTTPServer" -> "HTTP", "Server") and at a capital after a
+// digit ("v2Beta" -> "v2", "Beta"). Digits stay attached to the word before them.
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := current[len(current)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// capitalize upper-cases the first letter of word and lower-cases the rest.
+func capitalize(word string) string {
+	runes := []rune(strings.ToLower(word))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func joinWords(s, sep string, format func(i int, word string) string) string {
+	words := splitWords(s)
+	for i, w := range words {
+		words[i] = format(i, w)
+	}
+	return strings.Join(words, sep)
+}
+
+func lowerWord(_ int, w string) string { return strings.ToLower(w) }
+
+func toCamelCase(s string) string {
+	return joinWords(s, "", func(i int, w string) string {
+		if i == 0 {
+			return strings.ToLower(w)
+		}
+		return capitalize(w)
+	})
+}
+
+func toPascalCase(s string) string {
+	return joinWords(s, "", func(_ int, w string) string { return capitalize(w) })
+}
+
+func toTitleCase(s string) string {
+	return joinWords(s, " ", func(_ int, w string) string { return capitalize(w) })
+}
+
+func toScreamingSnake(s string) string {
+	return joinWords(s, "_", func(_ int, w string) string { return strings.ToUpper(w) })
+}
+
+func toSnakeCase(s string) string { return joinWords(s, "_", lowerWord) }
+func toKebabCase(s string) string { return joinWords(s, "-", lowerWord) }
+func toDotCase(s string) string   { return joinWords(s, ".", lowerWord) }
+func toPathCase(s string) string  { return joinWords(s, "/", lowerWord) }
+
+// caseTransformers are the case transformations.
+var caseTransformers = []Transformer{
+	simpleTransformer(TransformSpec{Name: "toUpperCase", Category: "case", Description: "Upper-cases the value",
+		Example: "[[APP_NAME:toUpperCase]]", Input: "my-app", Output: "MY-APP"}, strings.ToUpper),
+	simpleTransformer(TransformSpec{Name: "toLowerCase", Category: "case", Description: "Lower-cases the value",
+		Example: "[[APP_NAME:toLowerCase]]", Input: "My-App", Output: "my-app"}, strings.ToLower),
+	simpleTransformer(TransformSpec{Name: "toDownCase", Category: "case", Description: "Same as `toLowerCase`",
+		Example: "[[APP_NAME:toDownCase]]", Input: "My-App", Output: "my-app"}, strings.ToLower),
+	simpleTransformer(TransformSpec{Name: "toCamelCase", Category: "case", Description: "Words joined, all but the first capitalized",
+		Example: "[[NAME:toCamelCase]]", Input: "my service", Output: "myService"}, toCamelCase),
+	simpleTransformer(TransformSpec{Name: "toPascalCase", Category: "case", Description: "Words capitalized and joined",
+		Example: "[[NAME:toPascalCase]]", Input: "my service", Output: "MyService"}, toPascalCase),
+	simpleTransformer(TransformSpec{Name: "toSnakeCase", Category: "case", Description: "Lower-case words joined with `_`",
+		Example: "[[NAME:toSnakeCase]]", Input: "MyService", Output: "my_service"}, toSnakeCase),
+	simpleTransformer(TransformSpec{Name: "toKebabCase", Category: "case", Description: "Lower-case words joined with `-`",
+		Example: "[[NAME:toKebabCase]]", Input: "MyService", Output: "my-service"}, toKebabCase),
+	simpleTransformer(TransformSpec{Name: "toScreamingSnake", Category: "case", Description: "Upper-case words joined with `_`",
+		Example: "[[NAME:toScreamingSnake]]", Input: "my-service", Output: "MY_SERVICE"}, toScreamingSnake),
+	simpleTransformer(TransformSpec{Name: "toTitleCase", Category: "case", Description: "Words capitalized and joined with spaces",
+		Example: "[[NAME:toTitleCase]]", Input: "my_service", Output: "My Service"}, toTitleCase),
+	simpleTransformer(TransformSpec{Name: "toDotCase", Category: "case", Description: "Lower-case words joined with `.`",
+		Example: "[[NAME:toDotCase]]", Input: "MyService", Output: "my.service"}, toDotCase),
+	simpleTransformer(TransformSpec{Name: "toPathCase", Category: "case", Description: "Lower-case words joined with `/`",
+		Example: "[[NAME:toPathCase]]", Input: "MyService", Output: "my/service"}, toPathCase),
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"my service", []string{"my", "service"}},
+		{"myService", []string{"my", "Service"}},
+		{"MyService", []string{"My", "Service"}},
+		{"my_service-name.v2", []string{"my", "service", "name", "v2"}},
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"parseHTTPResponse", []string{"parse", "HTTP", "Response"}},
+		{"userID", []string{"user", "ID"}},
+		{"APIKey2FA", []string{"API", "Key2", "FA"}},
+		{"ipv4Address", []string{"ipv4", "Address"}},
+		{"v2Beta", []string{"v2", "Beta"}},
+		{"OAuth2Client", []string{"O", "Auth2", "Client"}},
+		{"  --weird__spacing--  ", []string{"weird", "spacing"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := splitWords(tt.input)
+		if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("splitWords(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCaseTransformations(t *testing.T) {
+	fr := &FileReplacer{}
+	tests := []struct {
+		transform string
+		input     string
+		expected  string
+	}{
+		{"toCamelCase", "my service", "myService"},
+		{"toCamelCase", "HTTPServer", "httpServer"},
+		{"toCamelCase", "user_id", "userId"},
+		{"toPascalCase", "my service", "MyService"},
+		{"toPascalCase", "parse-http-response", "ParseHttpResponse"},
+		{"toSnakeCase", "MyService", "my_service"},
+		{"toSnakeCase", "parseHTTPResponse", "parse_http_response"},
+		{"toSnakeCase", "ipv4Address", "ipv4_address"},
+		{"toKebabCase", "My Service", "my-service"},
+		{"toKebabCase", "APIKey", "api-key"},
+		{"toScreamingSnake", "my-service", "MY_SERVICE"},
+		{"toScreamingSnake", "v2Beta", "V2_BETA"},
+		{"toTitleCase", "my_service", "My Service"},
+		{"toTitleCase", "HTTP server", "Http Server"},
+		{"toDotCase", "MyService", "my.service"},
+		{"toPathCase", "com.acme.MyApp", "com/acme/my/app"},
+	}
+	for _, tt := range tests {
+		got, err := applyChain(fr, tt.input, tt.transform)
+		if err != nil {
+			t.Fatalf("%s(%q) failed: %v", tt.transform, tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s(%q) = %q, expected %q", tt.transform, tt.input, got, tt.expected)
+		}
+	}
+
+	// case transformations chain with the existing ones
+	got, err := applyChain(fr, "my service", "toPascalCase", "gsub(Service,App)")
+	if err != nil || got != "MyApp" {
+		t.Errorf("chained transformations = %q (%v), expected %q", got, err, "MyApp")
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Conditions are written in prefix notation and shared by block tags
+// ([[#if ...]]) and every other place that decides whether something applies:
+//
+//	USE_DB                      truthy when set and not false/0/no/off
+//	not USE_DB
+//	eq DB "postgres"            also: ne
+//	and USE_DB (eq DB "mysql")  also: or; both take two or more operands
+//
+// Bare words are variable names; literals are quoted with " or '.
+
+// EvalCondition evaluates expr, resolving variables through lookup.
+// Unknown variables are empty and therefore false.
+func EvalCondition(expr string, lookup func(key string) (string, bool)) (bool, error) {
+	c, err := parseCondition(expr)
+	if err != nil {
+		return false, err
+	}
+	return truthy(c.eval(lookup)), nil
+}
+
+// ConditionRefs returns the variable names referenced by expr, in order of appearance.
+func ConditionRefs(expr string) ([]string, error) {
+	c, err := parseCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	c.refs(&refs)
+	return refs, nil
+}
+
+// truthy reports whether a value counts as true in a condition.
+func truthy(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "false", "0", "no", "off", "n":
+		return false
+	}
+	return true
+}
+
+type condExpr struct {
+	op      string // "var", "lit" or a function name
+	value   string
+	operand []*condExpr
+}
+
+func (c *condExpr) eval(lookup func(string) (string, bool)) string {
+	boolStr := func(b bool) string {
+		if b {
+			return "true"
+		}
+		return "false"
+	}
+	switch c.op {
+	case "lit":
+		return c.value
+	case "var":
+		v, _ := lookup(c.value)
+		return v
+	case "not":
+		return boolStr(!truthy(c.operand[0].eval(lookup)))
+	case "eq":
+		return boolStr(c.operand[0].eval(lookup) == c.operand[1].eval(lookup))
+	case "ne":
+		return boolStr(c.operand[0].eval(lookup) != c.operand[1].eval(lookup))
+	case "and":
+		for _, o := range c.operand {
+			if !truthy(o.eval(lookup)) {
+				return "false"
+			}
+		}
+		return "true"
+	case "or":
+		for _, o := range c.operand {
+			if truthy(o.eval(lookup)) {
+				return "true"
+			}
+		}
+		return "false"
+	}
+	return ""
+}
+
+func (c *condExpr) refs(into *[]string) {
+	if c.op == "var" {
+		*into = append(*into, c.value)
+	}
+	for _, o := range c.operand {
+		o.refs(into)
+	}
+}
+
+// condArity is the number of operands each function takes; -1 means two or more.
+var condArity = map[string]int{"not": 1, "eq": 2, "ne": 2, "and": -1, "or": -1}
+
+func parseCondition(expr string) (*condExpr, error) {
+	tokens, err := lexCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	p := &condParser{tokens: tokens}
+	// the outermost call does not need parentheses: "eq A B" == "(eq A B)"
+	var c *condExpr
+	if _, isFunc := condArity[tokens[0]]; isFunc {
+		c, err = p.call(len(tokens))
+	} else {
+		c, err = p.operand()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(tokens) {
+		return nil, fmt.Errorf("unexpected %q in condition %q", tokens[p.pos], expr)
+	}
+	return c, nil
+}
+
+type condParser struct {
+	tokens []string
+	pos    int
+}
+
+// call parses "fn operand..." stopping at ")" or at the token index end.
+func (p *condParser) call(end int) (*condExpr, error) {
+	fn := p.tokens[p.pos]
+	p.pos++
+	c := &condExpr{op: fn}
+	for p.pos < end && p.tokens[p.pos] != ")" {
+		o, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		c.operand = append(c.operand, o)
+	}
+	arity := condArity[fn]
+	if (arity == -1 && len(c.operand) < 2) || (arity > 0 && len(c.operand) != arity) {
+		want := fmt.Sprintf("%d", arity)
+		if arity == -1 {
+			want = "at least 2"
+		}
+		return nil, fmt.Errorf("%s expects %s operand(s), got %d", fn, want, len(c.operand))
+	}
+	return c, nil
+}
+
+func (p *condParser) operand() (*condExpr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("missing operand")
+	}
+	tok := p.tokens[p.pos]
+	switch {
+	case tok == "(":
+		p.pos++
+		if p.pos >= len(p.tokens) {
+			return nil, fmt.Errorf("unclosed '('")
+		}
+		var c *condExpr
+		var err error
+		if _, isFunc := condArity[p.tokens[p.pos]]; isFunc {
+			c, err = p.call(len(p.tokens))
+		} else {
+			c, err = p.operand()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, fmt.Errorf("unclosed '('")
+		}
+		p.pos++
+		return c, nil
+	case tok == ")":
+		return nil, fmt.Errorf("unexpected ')'")
+	case strings.HasPrefix(tok, `"`) || strings.HasPrefix(tok, `'`):
+		p.pos++
+		return &condExpr{op: "lit", value: tok[1 : len(tok)-1]}, nil
+	case tok == "true" || tok == "false":
+		p.pos++
+		return &condExpr{op: "lit", value: tok}, nil
+	}
+	if _, isFunc := condArity[tok]; isFunc {
+		return nil, fmt.Errorf("%s must be wrapped in parentheses when used as an operand", tok)
+	}
+	p.pos++
+	return &condExpr{op: "var", value: tok}, nil
+}
+
+// lexCondition splits a condition into words, parentheses and quoted literals
+// (kept with their quotes so they can be told apart from variable names).
+func lexCondition(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated string in condition %q", expr)
+			}
+			tokens = append(tokens, expr[i:i+end+2])
+			i += end + 2
+		default:
+			j := i
+			for j < len(expr) && !strings.ContainsRune(" \t()\"'", rune(expr[j])) {
+				j++
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+// Delimiter rules pick the placeholder delimiters per file, for trees that mix file
+// types where the default delimiters already mean something:
+//
+//	delimiters:
+//	  - path: "*.sh"
+//	    start: "<%"
+//	    end: "%>"
+//	  - path: "charts/**"
+//	    start: "[["
+//	    end: "]]"
+//
+// Paths use gitignore syntax and are matched against the path relative to the
+// processed directory, with and without the template suffix; the last matching rule
+// wins. Other files, and file and directory names, use the default delimiters.
+// Negated paths ("!*.sh") are rejected rather than read as the paths they leave out.
+
+type delimiterRule struct {
+	rule  ignoreRule
+	start string
+	end   string
+}
+
+func compileDelimiterRules(rules []domain.DelimiterRule) ([]delimiterRule, error) {
+	var compiled []delimiterRule
+	for _, d := range rules {
+		if strings.HasPrefix(d.Path, "!") {
+			return nil, fmt.Errorf("delimiters %q: negated paths are not supported, list the paths the delimiters apply to", d.Path)
+		}
+		r, ok := compileIgnoreRule(d.Path)
+		if !ok {
+			return nil, fmt.Errorf("delimiters %q: invalid pattern", d.Path)
+		}
+		if d.Start == "" || d.End == "" {
+			return nil, fmt.Errorf("delimiters %q: start and end must not be empty", d.Path)
+		}
+		compiled = append(compiled, delimiterRule{rule: r, start: d.Start, end: d.End})
+	}
+	return compiled, nil
+}
+
+// WithDelimiters returns a copy of the replacer that uses the delimiter rules for the
+// files they match, falling back to the delimiters passed to each call.
+func (fr *FileReplacer) WithDelimiters(rules []domain.DelimiterRule) (Replacer, error) {
+	compiled, err := compileDelimiterRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	clone := *fr
+	clone.delimiters = compiled
+	return &clone, nil
+}
+
+// delimitersFor returns the delimiters for the file at rel (slash-separated, relative
+// to the processed directory).
+func (fr *FileReplacer) delimitersFor(rel, startDelim, endDelim string) (string, string) {
+	target, _ := fr.templates.trimPath(rel)
+	for _, d := range fr.delimiters {
+		if matchesFile(d.rule, rel) || matchesFile(d.rule, target) {
+			startDelim, endDelim = d.start, d.end
+		}
+	}
+	return startDelim, endDelim
+}
+
+// matchesFile reports whether r matches the file at rel or one of its parent directories.
+func matchesFile(r ignoreRule, rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if r.re.MatchString(strings.Join(parts[:i], "/")) {
+			return true
+		}
+	}
+	return !r.dirOnly && r.re.MatchString(rel)
+}
+
+// DelimiterStyle formats a delimiter pair the way it is shown in summaries.
+func DelimiterStyle(startDelim, endDelim string) string {
+	return startDelim + " " + endDelim
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func TestDelimitersFor(t *testing.T) {
+	r, err := (&FileReplacer{}).WithDelimiters([]domain.DelimiterRule{
+		{Path: "*.sh", Start: "<%", End: "%>"},
+		{Path: "charts/", Start: "((", End: "))"},
+		{Path: "charts/legacy.sh", Start: "@@", End: "@@"},
+	})
+	if err != nil {
+		t.Fatalf("WithDelimiters failed: %v", err)
+	}
+	fr := r.(*FileReplacer)
+
+	tests := []struct {
+		rel   string
+		start string
+		end   string
+	}{
+		{"main.go", "[[", "]]"},
+		{"run.sh", "<%", "%>"},
+		{"scripts/deploy.sh", "<%", "%>"},
+		{"scripts/deploy.sh.tpl", "<%", "%>"},
+		{"charts/values.yaml", "((", "))"},
+		{"charts/templates/app.sh", "((", "))"},
+		{"charts/legacy.sh", "@@", "@@"},
+		{"charts", "[[", "]]"},
+	}
+	for _, tt := range tests {
+		start, end := fr.delimitersFor(tt.rel, "[[", "]]")
+		if start != tt.start || end != tt.end {
+			t.Errorf("%s: got %s %s, expected %s %s", tt.rel, start, end, tt.start, tt.end)
+		}
+	}
+
+	if _, err := (&FileReplacer{}).WithDelimiters([]domain.DelimiterRule{{Path: "*.sh", Start: "<%"}}); err == nil || !strings.Contains(err.Error(), `delimiters "*.sh": start and end must not be empty`) {
+		t.Errorf("expected an empty delimiter error, got %v", err)
+	}
+	if _, err := (&FileReplacer{}).WithDelimiters([]domain.DelimiterRule{{Path: "", Start: "<%", End: "%>"}}); err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Errorf("expected an invalid pattern error, got %v", err)
+	}
+	if _, err := (&FileReplacer{}).WithDelimiters([]domain.DelimiterRule{{Path: "!*.sh", Start: "<%", End: "%>"}}); err == nil || !strings.Contains(err.Error(), `delimiters "!*.sh": negated paths are not supported`) {
+		t.Errorf("expected a negated path error, got %v", err)
+	}
+}
+
+func TestPerFileDelimiters(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"run.sh":          "if [[ -n \"$X\" ]]; then echo <%APP_NAME:toUpperCase%>; fi\n",
+		"app.yaml":        "name: [[APP_NAME]]\nport: [[PORT|8080]]\n",
+		"chart/app.yaml":  "image: {{ .Values.image }}\nname: [[APP_NAME]]\n",
+		"notes.sh.tpl":    "echo <%PORT%> [[PORT]]\n",
+		"[[APP_NAME]].md": "# <%APP_NAME%>\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	r, err := (&FileReplacer{FileSystem: &OsFileSystem{}}).WithDelimiters([]domain.DelimiterRule{{Path: "*.sh", Start: "<%", End: "%>"}})
+	if err != nil {
+		t.Fatalf("WithDelimiters failed: %v", err)
+	}
+
+	infos, err := r.AnalyzePlaceholders(tempDir, nil, "3 mb", "[[", "]]", false)
+	if err != nil {
+		t.Fatalf("AnalyzePlaceholders failed: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected APP_NAME and PORT, got %v", infos)
+	}
+	// run.sh, app.yaml, chart/app.yaml and the file name use APP_NAME
+	if a := infos["APP_NAME"]; a.Count != 4 || !reflect.DeepEqual(a.Styles, []string{"[[ ]]", "<% %>"}) {
+		t.Errorf("unexpected APP_NAME info %+v", a)
+	}
+	if p := infos["PORT"]; p.Count != 2 || !p.HasDefault || len(p.Styles) != 2 {
+		t.Errorf("unexpected PORT info %+v", p)
+	}
+
+	repl := domain.InputReplacement{Variables: []domain.Replacement{{Key: "APP_NAME", Value: "demo"}, {Key: "PORT", Value: "9000"}}}
+	if err := r.ReplaceInDir(tempDir, repl, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+	if err := r.ProcessTemplateFiles(tempDir, repl, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ProcessTemplateFiles failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"run.sh":         "if [[ -n \"$X\" ]]; then echo DEMO; fi\n",
+		"app.yaml":       "name: demo\nport: 9000\n",
+		"chart/app.yaml": "image: {{ .Values.image }}\nname: demo\n",
+		"notes.sh":       "echo 9000 [[PORT]]\n",
+		"demo.md":        "# <%APP_NAME%>\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(tempDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Derived variables are values file entries whose value references other variables:
+//
+//	- key: MODULE_PATH
+//	  value: "github.com/[[ORG]]/[[APP_NAME:toLowerCase]]"
+//
+// They are rendered with the same engine as files, after every variable they
+// depend on, so they can build on each other as long as there is no cycle. A value
+// that only contains delimiters, such as the TOML table header "[[servers]]", is not
+// derived unless it references a variable that is known to the run.
+
+// IsDerived reports whether value parses as a template referencing at least one
+// variable for which known returns true.
+func IsDerived(value, startDelim, endDelim string, known func(key string) bool) bool {
+	if !strings.Contains(value, startDelim) || !strings.Contains(value, endDelim) {
+		return false
+	}
+	refs, err := VariableRefs(value, startDelim, endDelim)
+	if err != nil {
+		return false
+	}
+	for _, ref := range refs {
+		if known(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// VariableRefs returns the variables referenced by a derived value, sorted.
+func VariableRefs(value, startDelim, endDelim string) ([]string, error) {
+	nodes, err := parseTemplate("", value, startDelim, endDelim)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]*PlaceholderInfo{}
+	(&FileReplacer{}).collectTemplateRefs("", nodes, nil, result)
+	refs := make([]string, 0, len(result))
+	for ref := range result {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
+
+// ResolveDerived renders the derived values in dependency order on top of values and
+// returns the combined set. A cycle between derived values is an error.
+func (fr *FileReplacer) ResolveDerived(values map[string]string, derived map[string]string, startDelim string, endDelim string) (map[string]string, error) {
+	resolved := make(map[string]string, len(values)+len(derived))
+	for k, v := range values {
+		resolved[k] = v
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var stack []string
+	var resolve func(key string) error
+	resolve = func(key string) error {
+		switch state[key] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for i, k := range stack {
+				if k == key {
+					start = i
+				}
+			}
+			return fmt.Errorf("derived variables form a cycle: %s -> %s", strings.Join(stack[start:], " -> "), key)
+		}
+		state[key] = visiting
+		stack = append(stack, key)
+
+		refs, err := VariableRefs(derived[key], startDelim, endDelim)
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", key, err)
+		}
+		for _, ref := range refs {
+			if _, ok := derived[ref]; ok {
+				if err := resolve(ref); err != nil {
+					return err
+				}
+			}
+		}
+		value, _, err := fr.renderText("variable "+key, derived[key], &renderScope{values: resolved}, startDelim, endDelim)
+		if err != nil {
+			return err
+		}
+		resolved[key] = value
+
+		stack = stack[:len(stack)-1]
+		state[key] = done
+		return nil
+	}
+
+	keys := make([]string, 0, len(derived))
+	for k := range derived {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := resolve(k); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
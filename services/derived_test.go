@@ -0,0 +1,82 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveDerived(t *testing.T) {
+	fr := &FileReplacer{}
+	values := map[string]string{"ORG": "acme", "APP_NAME": "My-App"}
+	derived := map[string]string{
+		"MODULE_PATH": "github.com/[[ORG]]/[[APP_NAME:toLowerCase]]",
+		"IMPORT_PATH": "[[MODULE_PATH]]/pkg",
+		"IMAGE":       "ghcr.io/[[IMPORT_PATH:gsub(github.com/,)]]:[[TAG|latest]]",
+	}
+	got, err := fr.ResolveDerived(values, derived, "[[", "]]")
+	if err != nil {
+		t.Fatalf("ResolveDerived failed: %v", err)
+	}
+	expected := map[string]string{
+		"ORG":         "acme",
+		"APP_NAME":    "My-App",
+		"MODULE_PATH": "github.com/acme/my-app",
+		"IMPORT_PATH": "github.com/acme/my-app/pkg",
+		"IMAGE":       "ghcr.io/acme/my-app/pkg:latest",
+	}
+	for k, want := range expected {
+		if got[k] != want {
+			t.Errorf("%s: expected %q, got %q", k, want, got[k])
+		}
+	}
+	if _, changed := values["MODULE_PATH"]; changed {
+		t.Error("ResolveDerived should not modify the input values")
+	}
+}
+
+func TestResolveDerivedDetectsCycles(t *testing.T) {
+	fr := &FileReplacer{}
+	derived := map[string]string{
+		"A": "[[B]]-a",
+		"B": "[[C]]-b",
+		"C": "[[#if X]][[A]][[/if]]-c",
+		"D": "[[A]]",
+	}
+	_, err := fr.ResolveDerived(map[string]string{}, derived, "[[", "]]")
+	if err == nil {
+		t.Fatal("expected a cycle error")
+	}
+	if !strings.Contains(err.Error(), "A -> B -> C -> A") {
+		t.Errorf("expected the cycle to be reported, got %q", err.Error())
+	}
+
+	_, err = fr.ResolveDerived(map[string]string{}, map[string]string{"SELF": "x[[SELF]]"}, "[[", "]]")
+	if err == nil || !strings.Contains(err.Error(), "SELF -> SELF") {
+		t.Errorf("expected a self reference error, got %v", err)
+	}
+}
+
+func TestVariableRefs(t *testing.T) {
+	refs, err := VariableRefs("[[#if USE_DB]][[DB_HOST|localhost]][[/if]]/[[APP:toUpperCase]]", "[[", "]]")
+	if err != nil {
+		t.Fatalf("VariableRefs failed: %v", err)
+	}
+	if strings.Join(refs, ",") != "APP,DB_HOST,USE_DB" {
+		t.Errorf("unexpected refs %v", refs)
+	}
+
+	known := func(k string) bool { return k == "ORG" }
+	tests := map[string]bool{
+		"github.com/[[ORG]]":           true,
+		"[[ORG]]/[[TAG|latest]]":       true,
+		"plain":                        false,
+		"[[servers]]":                  false,
+		"[[ -n \"$A\" || -z \"$B\" ]]": false,
+		"[[#if ORG]]":                  false,
+	}
+	for value, want := range tests {
+		if got := IsDerived(value, "[[", "]]", known); got != want {
+			t.Errorf("IsDerived(%q) = %v, expected %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+func encodingTransformer(spec TransformSpec, fn func(string) (string, error)) Transformer {
+	spec.Category = "encoding"
+	return NewTransformer(spec, func(v string, _ TransformArgs) (string, error) { return fn(v) })
+}
+
+// encodingTransformers make values safe for the file they are written into, or
+// encode and hash them.
+var encodingTransformers = []Transformer{
+	encodingTransformer(TransformSpec{Name: "jsonEscape", Description: "Escapes for use inside a JSON string (quotes not added)",
+		Example: "[[COMPANY:jsonEscape]]", Input: `Acme "Tools"`, Output: `Acme \"Tools\"`}, jsonEscape),
+	encodingTransformer(TransformSpec{Name: "yamlQuote", Description: "Double-quoted YAML scalar, quotes included",
+		Example: "[[ENABLED:yamlQuote]]", Input: "yes", Output: `"yes"`}, yamlQuote),
+	encodingTransformer(TransformSpec{Name: "shellQuote", Description: "Single-quoted POSIX shell word",
+		Example: "[[GREETING:shellQuote]]", Input: "it's $HOME", Output: `'it'\''s $HOME'`}, func(v string) (string, error) {
+		return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'", nil
+	}),
+	encodingTransformer(TransformSpec{Name: "xmlEscape", Description: "Escapes `<`, `>`, `&`, quotes and apostrophes for XML/HTML",
+		Example: "[[TITLE:xmlEscape]]", Input: "Tom & Jerry", Output: "Tom &amp; Jerry"}, func(v string) (string, error) {
+		var buf bytes.Buffer
+		if err := xml.EscapeText(&buf, []byte(v)); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}),
+	encodingTransformer(TransformSpec{Name: "urlEncode", Description: "Query-string encoding (spaces become `+`)",
+		Example: "[[QUERY:urlEncode]]", Input: "a b&c", Output: "a+b%26c"}, func(v string) (string, error) {
+		return url.QueryEscape(v), nil
+	}),
+	encodingTransformer(TransformSpec{Name: "base64", Description: "Standard base64 encoding",
+		Example: "[[SECRET:base64]]", Input: "hello", Output: "aGVsbG8="}, func(v string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(v)), nil
+	}),
+	encodingTransformer(TransformSpec{Name: "base64Decode", Description: "Decodes standard base64; invalid input stops the run",
+		Example: "[[SECRET:base64Decode]]", Input: "aGVsbG8=", Output: "hello"}, func(v string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v))
+		if err != nil {
+			return "", fmt.Errorf("invalid base64 input: %v", err)
+		}
+		return string(decoded), nil
+	}),
+	encodingTransformer(TransformSpec{Name: "sha256", Description: "Hex-encoded SHA-256 digest",
+		Example: "[[CONTENT:sha256]]", Input: "abc", Output: "ba7816bf..."}, func(v string) (string, error) {
+		sum := sha256.Sum256([]byte(v))
+		return hex.EncodeToString(sum[:]), nil
+	}),
+	encodingTransformer(TransformSpec{Name: "md5", Description: "Hex-encoded MD5 digest (for checksums, not security)",
+		Example: "[[CONTENT:md5]]", Input: "abc", Output: "90015098..."}, func(v string) (string, error) {
+		sum := md5.Sum([]byte(v))
+		return hex.EncodeToString(sum[:]), nil
+	}),
+}
+
+// jsonString encodes v as a JSON string literal, leaving <, > and & as they are.
+func jsonString(v string) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// jsonEscape escapes v for use inside a JSON string, without the surrounding quotes.
+func jsonEscape(v string) (string, error) {
+	s, err := jsonString(v)
+	if err != nil {
+		return "", err
+	}
+	return s[1 : len(s)-1], nil
+}
+
+// yamlQuote returns v as a double-quoted YAML scalar. JSON string literals are valid
+// YAML, so the JSON escaping rules are reused.
+func yamlQuote(v string) (string, error) {
+	return jsonString(v)
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodingTransformations(t *testing.T) {
+	fr := &FileReplacer{}
+	tests := []struct {
+		input      string
+		transforms []string
+		expected   string
+	}{
+		{`Acme "Tools" <x> & co\n`, []string{"jsonEscape"}, `Acme \"Tools\" <x> & co\\n`},
+		{"line1\nline2\ttab", []string{"jsonEscape"}, `line1\nline2\ttab`},
+		{"My App", []string{"toLowerCase", "jsonEscape"}, "my app"},
+		{"yes", []string{"yamlQuote"}, `"yes"`},
+		{`a: "b" # c`, []string{"yamlQuote"}, `"a: \"b\" # c"`},
+		{"it's $HOME", []string{"shellQuote"}, `'it'\''s $HOME'`},
+		{`<a href="x">Tom & Jerry's</a>`, []string{"xmlEscape"}, "&lt;a href=&#34;x&#34;&gt;Tom &amp; Jerry&#39;s&lt;/a&gt;"},
+		{"a b&c=d/é", []string{"urlEncode"}, "a+b%26c%3Dd%2F%C3%A9"},
+		{"hello world", []string{"base64"}, "aGVsbG8gd29ybGQ="},
+		{"aGVsbG8gd29ybGQ=", []string{"base64Decode"}, "hello world"},
+		{"round trip", []string{"base64", "base64Decode"}, "round trip"},
+		{"abc", []string{"sha256"}, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"abc", []string{"md5"}, "900150983cd24fb0d6963f7d28e17f72"},
+		{"My Service", []string{"toKebabCase", "sha256", "truncate(8)"}, "e43fea3e"},
+	}
+	for _, tt := range tests {
+		got, err := applyChain(fr, tt.input, tt.transforms...)
+		if err != nil {
+			t.Fatalf("%v on %q failed: %v", tt.transforms, tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%v on %q = %q, expected %q", tt.transforms, tt.input, got, tt.expected)
+		}
+	}
+
+	_, err := applyChain(fr, "not base64!", "base64Decode")
+	if err == nil || !strings.Contains(err.Error(), "invalid base64 input") {
+		t.Errorf("expected a base64 error, got %v", err)
+	}
+}
@@ -15,6 +15,8 @@ type FileSystem interface {
 	EnsureDir(path string) error
 	Join(elem ...string) string
 	Remove(path string) error
+	Rename(oldPath, newPath string) error
+	Chmod(path string, mode fs.FileMode) error
 	Base(path string) string
 }
 
@@ -65,6 +67,14 @@ func (o *OsFileSystem) Remove(path string) error {
 	return os.Remove(path)
 }
 
+func (o *OsFileSystem) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+func (o *OsFileSystem) Chmod(path string, mode fs.FileMode) error {
+	return os.Chmod(path, mode)
+}
+
 func (o *OsFileSystem) Base(path string) string {
 	return filepath.Base(path)
 }
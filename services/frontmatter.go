@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A .tpl file may start with front matter that controls where and how it is written:
+//
+//	---
+//	dest: /bin/[[APP_NAME]].sh
+//	mode: 0755
+//	when: and USE_DB (ne DB "sqlite")
+//	overwrite: if-unchanged
+//	---
+//	#!/bin/sh
+//
+// dest is templated like a file name and is relative to the directory of the .tpl, or
+// to the root with a leading "/". when uses the [[#if ...]] condition syntax; when it is
+// false the template is dropped. overwrite decides what happens to an existing
+// destination: always (the default) replaces it, never keeps it and if-unchanged only
+// replaces a pristine copy, one identical to the template or to the rendered output.
+// The block only counts as front matter when all of its keys are known, so YAML
+// templates that start with a "---" document marker are left alone.
+
+// Overwrite policies for front matter.
+const (
+	OverwriteAlways      = "always"
+	OverwriteNever       = "never"
+	OverwriteIfUnchanged = "if-unchanged"
+)
+
+type frontMatter struct {
+	dest      string
+	mode      fs.FileMode
+	hasMode   bool
+	when      string
+	overwrite string
+	lines     int // lines taken by the block, to report body errors at their real line
+}
+
+var frontMatterKeys = map[string]bool{"dest": true, "mode": true, "when": true, "overwrite": true}
+
+// splitFrontMatter returns the front matter of a template, zero when it has none, and
+// the body that follows it.
+func splitFrontMatter(file, content string) (frontMatter, string, error) {
+	var fm frontMatter
+	lines := strings.SplitAfter(content, "\n")
+	if strings.TrimRight(lines[0], "\r\n") != "---" {
+		return fm, content, nil
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], "\r\n") == "---" {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return fm, content, nil
+	}
+
+	var header map[string]yaml.Node
+	if yaml.Unmarshal([]byte(strings.Join(lines[1:end], "")), &header) != nil || len(header) == 0 {
+		return fm, content, nil
+	}
+	for key := range header {
+		if !frontMatterKeys[key] {
+			return fm, content, nil
+		}
+	}
+
+	// the literal text is used, so mode: 0755 is not read as a decimal number
+	fm.dest = header["dest"].Value
+	fm.when = header["when"].Value
+	fm.overwrite = header["overwrite"].Value
+	fm.lines = end + 1
+	switch fm.overwrite {
+	case "", OverwriteAlways, OverwriteNever, OverwriteIfUnchanged:
+	default:
+		return fm, "", fmt.Errorf("%s: front matter: invalid overwrite %q: expected %s, %s or %s", file, fm.overwrite, OverwriteAlways, OverwriteNever, OverwriteIfUnchanged)
+	}
+	if mode := header["mode"].Value; mode != "" {
+		m, err := strconv.ParseUint(strings.TrimPrefix(mode, "0o"), 8, 32)
+		if err != nil || m > 0777 {
+			return fm, "", fmt.Errorf("%s: front matter: invalid mode %q: expected octal permissions such as 0755", file, mode)
+		}
+		fm.mode, fm.hasMode = fs.FileMode(m), true
+	}
+	return fm, strings.Join(lines[end+1:], ""), nil
+}
+
+// shiftTemplateError moves the line of an error in the body of file past its front matter.
+func (fm frontMatter) shiftTemplateError(file string, err error) error {
+	var te *TemplateError
+	if fm.lines > 0 && errors.As(err, &te) && te.File == file && te.Line > 0 {
+		te.Line += fm.lines
+	}
+	return err
+}
+
+// frontMatterRefs adds the variables used by the destination and the condition of
+// the front matter to result.
+func (fr *FileReplacer) frontMatterRefs(file string, fm frontMatter, startDelim, endDelim string, result map[string]*PlaceholderInfo) error {
+	if err := fr.countTemplateRefs(file, fm.dest, startDelim, endDelim, nil, result); err != nil {
+		return err
+	}
+	if fm.when == "" {
+		return nil
+	}
+	refs, err := ConditionRefs(fm.when)
+	if err != nil {
+		return fmt.Errorf("%s: front matter: when %q: %w", file, fm.when, err)
+	}
+	for _, ref := range refs {
+		addPlaceholderRef(result, ref)
+	}
+	return nil
+}
+
+// templateDest returns the path a template is written to according to its front
+// matter dest. The destination must stay inside root.
+func (fr *FileReplacer) templateDest(root, path string, fm frontMatter, values *renderScope, startDelim, endDelim string) (string, error) {
+	dest, _, err := fr.renderText(path, fm.dest, values, startDelim, endDelim)
+	if err != nil {
+		return "", err
+	}
+	rel := filepath.FromSlash(dest)
+	if !strings.HasPrefix(dest, "/") {
+		if rel, err = filepath.Rel(root, fr.FileSystem.Join(filepath.Dir(path), rel)); err != nil {
+			return "", err
+		}
+	}
+	rel = filepath.Clean(strings.TrimPrefix(rel, string(filepath.Separator)))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s: front matter: dest %q is outside the template", path, dest)
+	}
+	return fr.FileSystem.Join(root, rel), nil
+}
+
+// keepExisting reports whether the overwrite policy keeps the file already at dest
+// instead of replacing it with rendered, the output of the template body.
+func (fr *FileReplacer) keepExisting(dest string, fm frontMatter, body, rendered string) (bool, error) {
+	if fm.overwrite == "" || fm.overwrite == OverwriteAlways {
+		return false, nil
+	}
+	info, err := fr.FileSystem.Stat(dest)
+	if err != nil {
+		return false, nil
+	}
+	if info.IsDir() {
+		return false, fmt.Errorf("front matter: dest %s is a directory", dest)
+	}
+	if fm.overwrite == OverwriteNever {
+		return true, nil
+	}
+	existing, err := fr.FileSystem.ReadFile(dest)
+	if err != nil {
+		return false, err
+	}
+	return string(existing) != body && string(existing) != rendered, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected frontMatter
+		body     string
+	}{
+		{"full", "---\ndest: bin/[[APP]].sh\nmode: 0755\nwhen: USE_DB\noverwrite: never\n---\n#!/bin/sh\n",
+			frontMatter{dest: "bin/[[APP]].sh", mode: 0755, hasMode: true, when: "USE_DB", overwrite: "never", lines: 6}, "#!/bin/sh\n"},
+		{"crlf and quoted mode", "---\r\nmode: \"644\"\r\n---\r\nbody", frontMatter{mode: 0644, hasMode: true, lines: 3}, "body"},
+		{"no front matter", "name: x\n", frontMatter{}, "name: x\n"},
+		{"yaml documents", "---\nname: x\n---\nname: y\n", frontMatter{}, "---\nname: x\n---\nname: y\n"},
+		{"unterminated", "---\ndest: x\n", frontMatter{}, "---\ndest: x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body, err := splitFrontMatter("a.tpl", tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fm != tt.expected || body != tt.body {
+				t.Errorf("expected %+v %q, got %+v %q", tt.expected, tt.body, fm, body)
+			}
+		})
+	}
+
+	failures := []struct {
+		input    string
+		expected string
+	}{
+		{"---\nmode: rwx\n---\n", `a.tpl: front matter: invalid mode "rwx"`},
+		{"---\nmode: 01755\n---\n", `invalid mode "01755"`},
+		{"---\noverwrite: sometimes\n---\n", `invalid overwrite "sometimes": expected always, never or if-unchanged`},
+	}
+	for _, tt := range failures {
+		_, _, err := splitFrontMatter("a.tpl", tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%q: expected error containing %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestFrontMatterTemplates(t *testing.T) {
+	dir := writePartialsTree(t, map[string]string{
+		"scripts/run.sh.tpl":    "---\ndest: /bin/[[APP_NAME]].sh\nmode: 0755\n---\n#!/bin/sh\necho [[APP_NAME]]\n",
+		"db.yaml.tpl":           "---\nwhen: USE_DB\n---\ndb: [[DB_NAME]]\n",
+		"shared.txt.tpl":        "---\nmode: 0666\n---\nshared\n",
+		"keep.txt.tpl":          "---\noverwrite: never\n---\nnew [[APP_NAME]]\n",
+		"keep.txt":              "mine\n",
+		"pristine.txt.tpl":      "---\noverwrite: if-unchanged\n---\nnew [[APP_NAME]]\n",
+		"pristine.txt":          "new [[APP_NAME]]\n",
+		"edited.txt.tpl":        "---\noverwrite: if-unchanged\n---\nnew [[APP_NAME]]\n",
+		"edited.txt":            "edited\n",
+		"docs/escape.txt.tpl":   "---\ndest: ../../x\n---\n",
+		"manifest.yaml.tpl":     "---\nname: [[APP_NAME]]\n",
+		"errors/bad.txt.tpl":    "---\nwhen: USE_DB\n---\nok\n[[#if X]]\n",
+		"errors/bad-when.x.tpl": "---\nwhen: eq A\n---\n",
+	})
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	ignore := []string{"docs/", "errors/"}
+	infos, err := replacer.AnalyzePlaceholders(dir, ignore, "3 mb", "[[", "]]", true)
+	if err != nil {
+		t.Fatalf("AnalyzePlaceholders failed: %v", err)
+	}
+	for _, key := range []string{"APP_NAME", "USE_DB", "DB_NAME"} {
+		if infos[key] == nil {
+			t.Errorf("expected %s to be discovered, got %v", key, infos)
+		}
+	}
+
+	repl := domain.InputReplacement{
+		Variables:  []domain.Replacement{{Key: "APP_NAME", Value: "demo"}, {Key: "USE_DB", Value: "no"}},
+		IgnorePath: ignore,
+	}
+	if err := replacer.ProcessTemplateFiles(dir, repl, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ProcessTemplateFiles failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"bin/demo.sh":   "#!/bin/sh\necho demo\n",
+		"keep.txt":      "mine\n",
+		"pristine.txt":  "new demo\n",
+		"edited.txt":    "edited\n",
+		"manifest.yaml": "---\nname: demo\n",
+		"shared.txt":    "shared\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dir, "bin", "demo.sh")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("expected bin/demo.sh to be 0755, got %v (%v)", info, err)
+	}
+	// the declared mode wins over the umask
+	if info, err := os.Stat(filepath.Join(dir, "shared.txt")); err != nil || info.Mode().Perm() != 0666 {
+		t.Errorf("expected shared.txt to be 0666, got %v (%v)", info, err)
+	}
+	for _, name := range []string{"db.yaml", "db.yaml.tpl", "keep.txt.tpl", "edited.txt.tpl", "scripts/run.sh"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", name, err)
+		}
+	}
+
+	failures := []struct {
+		dir      string
+		expected string
+	}{
+		{"docs", `escape.txt.tpl: front matter: dest "../../x" is outside the template`},
+		{"errors", "bad.txt.tpl:5:1: unclosed [[#if X]]"},
+	}
+	for _, tt := range failures {
+		repl := domain.InputReplacement{Variables: []domain.Replacement{{Key: "USE_DB", Value: "true"}}, IgnorePath: []string{"bad-when.x.tpl"}}
+		err := replacer.ProcessTemplateFiles(filepath.Join(dir, tt.dir), repl, "3 mb", "[[", "]]", false)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.dir, tt.expected, err)
+		}
+	}
+	if _, err := replacer.AnalyzePlaceholders(filepath.Join(dir, "errors"), []string{"bad.txt.tpl"}, "3 mb", "[[", "]]", true); err == nil || !strings.Contains(err.Error(), `front matter: when "eq A"`) {
+		t.Errorf("expected a condition error, got %v", err)
+	}
+}
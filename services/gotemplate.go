@@ -0,0 +1,196 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+// Engines for .tpl files. The yankrun engine renders them like any other file; the
+// gotemplate engine renders them with text/template:
+//
+//	[[ if .USE_DB ]]db: [[ .DB_NAME | toSnakeCase ]][[ end ]]
+//	[[ range .SERVICES ]]- [[ .name ]][[ end ]]
+//
+// The configured delimiters are the template delimiters, the values are the data
+// ("true" and "false" become booleans, lists become slices of strings or maps) and
+// every transformation is a function whose last argument is the value, so it can end
+// a pipeline: [[ .SHA | substr 0 7 ]]. File names keep the yankrun syntax.
+const (
+	EngineYankrun    = "yankrun"
+	EngineGoTemplate = "gotemplate"
+)
+
+// WithEngine returns a copy of the replacer that renders .tpl files with engine.
+func (fr *FileReplacer) WithEngine(engine string) (Replacer, error) {
+	switch engine {
+	case "", EngineYankrun:
+		engine = EngineYankrun
+	case EngineGoTemplate:
+	default:
+		return nil, fmt.Errorf("unknown engine %q: expected %s or %s", engine, EngineYankrun, EngineGoTemplate)
+	}
+	clone := *fr
+	clone.engine = engine
+	return &clone, nil
+}
+
+// templateFuncs exposes the transformations to text/template, plus truthy for
+// conditions that should follow [[#if]] rules ("no", "off" and "0" are false).
+func (fr *FileReplacer) templateFuncs() template.FuncMap {
+	funcs := template.FuncMap{
+		"truthy": func(v interface{}) bool { return truthy(fmt.Sprint(v)) },
+	}
+	for _, name := range fr.transformRegistry().Names() {
+		name := name
+		funcs[name] = func(args ...interface{}) (string, error) {
+			if len(args) == 0 {
+				return "", fmt.Errorf("%s: missing value", name)
+			}
+			call := transformCall{name: name, text: name}
+			for _, a := range args[:len(args)-1] {
+				// quoted, so values are taken literally and never regrouped
+				call.args = append(call.args, transformArg{value: fmt.Sprint(a), quoted: true})
+			}
+			return fr.applyTransformations(fmt.Sprint(args[len(args)-1]), []transformCall{call})
+		}
+	}
+	return funcs
+}
+
+// parseGoTemplate parses text as a text/template named after file.
+func (fr *FileReplacer) parseGoTemplate(file, text, startDelim, endDelim string) (*template.Template, error) {
+	return template.New(file).Delims(startDelim, endDelim).Funcs(fr.templateFuncs()).Option("missingkey=error").Parse(text)
+}
+
+// goTemplateData builds the template data from the replacements.
+func goTemplateData(replacements domain.InputReplacement) map[string]interface{} {
+	data := map[string]interface{}{}
+	for _, r := range replacements.Variables {
+		switch {
+		case r.List != nil:
+			items := make([]interface{}, len(r.List))
+			for i, item := range r.List {
+				if item.Fields != nil {
+					items[i] = item.Fields
+				} else {
+					items[i] = item.Value
+				}
+			}
+			data[r.Key] = items
+		case r.Value == "true":
+			data[r.Key] = true
+		case r.Value == "false":
+			data[r.Key] = false
+		default:
+			data[r.Key] = r.Value
+		}
+	}
+	return data
+}
+
+// renderGoTemplate renders text with text/template. Variables the template uses but
+// that have no value render as empty strings and are false in conditions.
+func (fr *FileReplacer) renderGoTemplate(file, text string, data map[string]interface{}, startDelim, endDelim string) (string, int, error) {
+	tmpl, err := fr.parseGoTemplate(file, text, startDelim, endDelim)
+	if err != nil {
+		return "", 0, err
+	}
+	refs := goTemplateRefs(tmpl)
+	scoped := make(map[string]interface{}, len(data)+len(refs))
+	for k, v := range data {
+		scoped[k] = v
+	}
+	for ref := range refs {
+		if _, ok := scoped[ref]; !ok {
+			scoped[ref] = ""
+		}
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, scoped); err != nil {
+		return "", 0, err
+	}
+	count := 0
+	for _, n := range refs {
+		count += n
+	}
+	return sb.String(), count, nil
+}
+
+// countGoTemplateRefs adds the variables used by a text/template to result.
+func (fr *FileReplacer) countGoTemplateRefs(file, text, startDelim, endDelim string, result map[string]*PlaceholderInfo) error {
+	tmpl, err := fr.parseGoTemplate(file, text, startDelim, endDelim)
+	if err != nil {
+		return err
+	}
+	found := map[string]*PlaceholderInfo{}
+	for ref, n := range goTemplateRefs(tmpl) {
+		found[ref] = &PlaceholderInfo{Count: n}
+	}
+	mergePlaceholderInfo(result, found, DelimiterStyle(startDelim, endDelim))
+	return nil
+}
+
+// goTemplateRefs counts the top-level fields (.KEY or $.KEY) used by tmpl and the
+// templates it defines. Fields inside range and with refer to the new dot and are skipped.
+func goTemplateRefs(tmpl *template.Template) map[string]int {
+	refs := map[string]int{}
+	var walk func(node parse.Node, topDot bool)
+	walk = func(node parse.Node, topDot bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c, topDot)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, topDot)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Cmds {
+				walk(c, topDot)
+			}
+		case *parse.CommandNode:
+			for _, a := range n.Args {
+				walk(a, topDot)
+			}
+		case *parse.ChainNode:
+			walk(n.Node, topDot)
+		case *parse.FieldNode:
+			if topDot {
+				refs[n.Ident[0]]++
+			}
+		case *parse.VariableNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				refs[n.Ident[1]]++
+			}
+		case *parse.IfNode:
+			walk(n.Pipe, topDot)
+			walk(n.List, topDot)
+			walk(n.ElseList, topDot)
+		case *parse.RangeNode:
+			walk(n.Pipe, topDot)
+			walk(n.List, false)
+			walk(n.ElseList, topDot)
+		case *parse.WithNode:
+			walk(n.Pipe, topDot)
+			walk(n.List, false)
+			walk(n.ElseList, topDot)
+		case *parse.TemplateNode:
+			walk(n.Pipe, topDot)
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root, true)
+		}
+	}
+	return refs
+}
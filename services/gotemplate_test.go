@@ -0,0 +1,120 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func TestRenderGoTemplate(t *testing.T) {
+	repl := domain.InputReplacement{Variables: []domain.Replacement{
+		{Key: "APP_NAME", Value: "My Service"},
+		{Key: "USE_DB", Value: "false"},
+		{Key: "CACHE", Value: "no"},
+		{Key: "SHA", Value: "3f2a9c1d4e"},
+		{Key: "REGIONS", List: []domain.ListItem{{Value: "us"}, {Value: "eu"}}},
+		{Key: "SERVICES", List: []domain.ListItem{
+			{Fields: map[string]string{"name": "api", "port": "8080"}},
+			{Fields: map[string]string{"name": "web", "port": "3000"}},
+		}},
+	}}
+	data := goTemplateData(repl)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"field", "name: [[ .APP_NAME ]]", "name: My Service"},
+		{"transform pipeline", "[[ .APP_NAME | toKebabCase | toUpperCase ]]", "MY-SERVICE"},
+		{"transform with arguments", `[[ .SHA | substr 0 7 ]] [[ gsub " " "_" .APP_NAME ]]`, "3f2a9c1 My_Service"},
+		{"boolean values", "[[ if .USE_DB ]]db[[ else ]]nodb[[ end ]]", "nodb"},
+		{"truthy", "[[ if truthy .CACHE ]]cache[[ else ]]nocache[[ end ]]", "nocache"},
+		{"unset value", "[[ if .MISSING ]]x[[ end ]][[ .MISSING ]]!", "!"},
+		{"range over scalars", "[[ range $i, $r := .REGIONS ]][[ if $i ]],[[ end ]][[ $r ]][[ end ]]", "us,eu"},
+		{"range over maps", "[[ range .SERVICES ]][[ .name ]]=[[ .port ]];[[ end ]]", "api=8080;web=3000;"},
+		{"with and root", "[[ with .SERVICES ]][[ len . ]] for [[ $.APP_NAME ]][[ end ]]", "2 for My Service"},
+		{"define", `[[ define "hdr" ]]# [[ .APP_NAME ]][[ end ]][[ template "hdr" . ]]`, "# My Service"},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := fr.renderGoTemplate("app.tpl", tt.input, data, "[[", "]]")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	failures := []struct {
+		input    string
+		expected string
+	}{
+		{"[[ .SHA | substr 0 ]]", "substr expects 2 argument(s), got 1"},
+		{"[[ .SHA | unknownFunc ]]", `function "unknownFunc" not defined`},
+		{"x\n[[ if .USE_DB ]]", "template: app.tpl:2: unexpected EOF"},
+	}
+	for _, tt := range failures {
+		_, _, err := fr.renderGoTemplate("app.tpl", tt.input, data, "[[", "]]")
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestGoTemplateEngine(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"config.yaml.tpl": "[[ range .SERVICES ]]- [[ .name | toUpperCase ]]\n[[ end ]][[ if $.USE_DB ]]db: [[ .DB_NAME ]]\n[[ end ]]",
+		"README.md":       "# [[APP_NAME]]\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	r, err := (&FileReplacer{FileSystem: &OsFileSystem{}}).WithEngine(EngineGoTemplate)
+	if err != nil {
+		t.Fatalf("WithEngine failed: %v", err)
+	}
+	infos, err := r.AnalyzePlaceholders(tempDir, nil, "3 mb", "[[", "]]", false)
+	if err != nil {
+		t.Fatalf("AnalyzePlaceholders failed: %v", err)
+	}
+	for _, key := range []string{"APP_NAME", "SERVICES", "USE_DB", "DB_NAME"} {
+		if infos[key] == nil {
+			t.Errorf("expected %s to be discovered, got %v", key, infos)
+		}
+	}
+	if len(infos) != 4 {
+		t.Errorf("expected 4 placeholders, got %v", infos)
+	}
+
+	repl := domain.InputReplacement{Variables: []domain.Replacement{
+		{Key: "USE_DB", Value: "true"},
+		{Key: "DB_NAME", Value: "app"},
+		{Key: "SERVICES", List: []domain.ListItem{{Fields: map[string]string{"name": "api"}}}},
+	}}
+	if err := r.ProcessTemplateFiles(tempDir, repl, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ProcessTemplateFiles failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(tempDir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("config.yaml not created: %v", err)
+	}
+	if string(got) != "- API\ndb: app\n" {
+		t.Errorf("unexpected config.yaml %q", got)
+	}
+
+	if _, err := (&FileReplacer{}).WithEngine("jinja"); err == nil || !strings.Contains(err.Error(), `unknown engine "jinja"`) {
+		t.Errorf("expected an unknown engine error, got %v", err)
+	}
+}
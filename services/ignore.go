@@ -0,0 +1,156 @@
+package services
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// defaultIgnorePatterns are always applied before user patterns, so they can be
+// re-included with a negated pattern such as "!build/".
+var defaultIgnorePatterns = []string{".git/", "node_modules/", "vendor/", "dist/", "build/", "bin/"}
+
+// IgnoreMatcher matches slash-separated paths, relative to the walked root,
+// against gitignore-style patterns. Supported syntax:
+//   - "*", "?" and "[...]" globs that never cross a "/"
+//   - "**" to match any number of directories ("**/x", "a/**", "a/**/b")
+//   - a leading "!" to re-include a previously ignored path
+//   - a leading or inner "/" to anchor the pattern to the root
+//   - a trailing "/" to only match directories
+//
+// As in git, the last matching pattern wins.
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// NewIgnoreMatcher builds a matcher from the default patterns followed by the
+// given ones. Blank lines and "#" comments are skipped.
+func NewIgnoreMatcher(patterns []string) *IgnoreMatcher {
+	m := &IgnoreMatcher{}
+	for _, p := range append(append([]string{}, defaultIgnorePatterns...), patterns...) {
+		if r, ok := compileIgnoreRule(p); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+	return m
+}
+
+// Match reports whether rel (slash-separated, relative to the root) is ignored,
+// either directly or because one of its parent directories is.
+func (m *IgnoreMatcher) Match(rel string, isDir bool) bool {
+	rel = strings.Trim(path.Clean("/"+rel), "/")
+	if rel == "" {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.matchOne(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.matchOne(rel, isDir)
+}
+
+func (m *IgnoreMatcher) matchOne(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+func compileIgnoreRule(raw string) (ignoreRule, bool) {
+	p := strings.TrimRight(raw, " \t\r")
+	if p == "" || strings.HasPrefix(p, "#") {
+		return ignoreRule{}, false
+	}
+	r := ignoreRule{pattern: p}
+	if strings.HasPrefix(p, "!") {
+		r.negate = true
+		p = p[1:]
+	} else if strings.HasPrefix(p, `\!`) || strings.HasPrefix(p, `\#`) {
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "/") {
+		r.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	if p == "" {
+		return ignoreRule{}, false
+	}
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	sb.WriteString(globToRegexp(p))
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// globToRegexp translates a gitignore glob (without leading "!" or trailing "/")
+// into an unanchored regular expression body.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				i++
+				if atStart && i+1 < len(glob) && glob[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
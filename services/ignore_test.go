@@ -0,0 +1,119 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"basename glob at any depth", []string{"*.lock"}, "a/b/yarn.lock", false, true},
+		{"basename glob no match", []string{"*.lock"}, "a/b/yarn.json", false, false},
+		{"directory name", []string{"tmp"}, "src/tmp", true, true},
+		{"file inside ignored directory", []string{"tmp"}, "src/tmp/x.txt", false, true},
+		{"dir-only pattern skips files", []string{"logs/"}, "logs", false, false},
+		{"dir-only pattern matches dirs", []string{"logs/"}, "a/logs", true, true},
+		{"anchored with leading slash", []string{"/config.yaml"}, "sub/config.yaml", false, false},
+		{"anchored at root", []string{"/config.yaml"}, "config.yaml", false, true},
+		{"inner slash anchors", []string{"docs/*.md"}, "x/docs/a.md", false, false},
+		{"inner slash matches root", []string{"docs/*.md"}, "docs/a.md", false, true},
+		{"star does not cross slash", []string{"docs/*.md"}, "docs/sub/a.md", false, false},
+		{"leading double star", []string{"**/fixtures"}, "a/b/fixtures", true, true},
+		{"trailing double star", []string{"gen/**"}, "gen/a/b.go", false, true},
+		{"middle double star", []string{"a/**/z.txt"}, "a/z.txt", false, true},
+		{"middle double star deep", []string{"a/**/z.txt"}, "a/b/c/z.txt", false, true},
+		{"question mark", []string{"file?.txt"}, "file1.txt", false, true},
+		{"character class", []string{"file[0-9].txt"}, "file7.txt", false, true},
+		{"negated class", []string{"file[!0-9].txt"}, "file7.txt", false, false},
+		{"negation re-includes", []string{"*.md", "!README.md"}, "README.md", false, false},
+		{"last match wins", []string{"!README.md", "*.md"}, "README.md", false, true},
+		{"default dirs ignored", nil, "node_modules", true, true},
+		{"default dirs can be re-included", []string{"!build/"}, "build", true, false},
+		{"comments skipped", []string{"# *.go"}, "main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewIgnoreMatcher(tt.patterns)
+			if got := m.Match(tt.path, tt.isDir); got != tt.expected {
+				t.Errorf("Match(%q, %v) with %v = %v, expected %v", tt.path, tt.isDir, tt.patterns, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIgnorePatternsAppliedToAnalyzeAndReplace(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tempDir, "generated"), 0755); err != nil {
+		t.Fatalf("Failed to create subdirectory: %v", err)
+	}
+	files := map[string]string{
+		"main.go":           "name = [[NAME]]",
+		"deps.lock":         "name = [[NAME]]",
+		"generated/out.txt": "name = [[NAME]]",
+		"generated/keep.go": "name = [[NAME]]",
+		"build/tool.sh":     "name = [[NAME]]",
+		"readme.tpl":        "name = [[NAME]]",
+		"skip.tpl":          "name = [[NAME]]",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	ignore := []string{"*.lock", "generated/", "!generated/keep.go", "/skip.tpl"}
+
+	counts, err := replacer.AnalyzeDir(tempDir, ignore, "3 mb", "[[", "]]", false)
+	if err != nil {
+		t.Fatalf("AnalyzeDir failed: %v", err)
+	}
+	// main.go and readme.tpl; generated/ is excluded as a whole so keep.go stays ignored
+	if counts["NAME"] != 2 {
+		t.Errorf("expected 2 matches for NAME, got %d", counts["NAME"])
+	}
+
+	replacements := domain.InputReplacement{
+		Variables:  []domain.Replacement{{Key: "NAME", Value: "demo"}},
+		IgnorePath: ignore,
+	}
+	if err := replacer.ReplaceInDir(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+	if err := replacer.ProcessTemplateFiles(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ProcessTemplateFiles failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"main.go":           "name = demo",
+		"deps.lock":         "name = [[NAME]]",
+		"generated/out.txt": "name = [[NAME]]",
+		"generated/keep.go": "name = [[NAME]]",
+		"build/tool.sh":     "name = [[NAME]]",
+		"readme":            "name = demo",
+		"skip.tpl":          "name = [[NAME]]",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, string(got))
+		}
+	}
+}
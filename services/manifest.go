@@ -0,0 +1,345 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/brasa-ai/yankrun/domain"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A template can declare its variables in a yankrun.yaml manifest at its root:
+//
+//	variables:
+//	  - name: APP_NAME
+//	    description: Name of the service
+//	    pattern: "[a-z][a-z0-9-]*"
+//	    required: true
+//	  - name: DB
+//	    choices: [postgres, mysql, sqlite]
+//	    default: postgres
+//	  - name: PORT
+//	    type: int
+//	    min: 1
+//	    max: 65535
+//	    default: 8080
+//	  - name: API_TOKEN
+//	    secret: true
+//	sections:
+//	  - title: Database
+//	    ask_if: ne DB "sqlite"
+//	    variables:
+//	      - name: DB_NAME
+//	        default: app
+//	      - name: DB_REPLICAS
+//	        type: int
+//	        ask_if: eq DB "postgres"
+//	conditional_paths:
+//	  - path: migrations/
+//	    when: ne DB "sqlite"
+//
+// The manifest sets the order of the prompts, their defaults and the rules values
+// are checked against. Sections are asked for after the ungrouped variables, under
+// their title. ask_if conditions use the syntax of [[#if]] and may only refer to
+// variables declared before them. Once loaded, the manifest is not templated and is
+// removed from clone and generate output.
+
+// ManifestFile is the name of the manifest at the root of a template.
+const ManifestFile = "yankrun.yaml"
+
+// ManifestIgnorePattern keeps the manifest out of analysis and replacement, when added
+// to the ignore patterns.
+const ManifestIgnorePattern = "/" + ManifestFile
+
+type yamlManifest struct {
+	Variables []yamlVariable `yaml:"variables"`
+	Sections  []struct {
+		Title       string         `yaml:"title"`
+		Description string         `yaml:"description"`
+		AskIf       string         `yaml:"ask_if"`
+		Variables   []yamlVariable `yaml:"variables"`
+	} `yaml:"sections"`
+	PathConditions []domain.PathCondition `yaml:"conditional_paths"`
+}
+
+type yamlVariable struct {
+	Name        string    `yaml:"name"`
+	Description string    `yaml:"description"`
+	Type        string    `yaml:"type"`
+	Default     yaml.Node `yaml:"default"`
+	Pattern     string    `yaml:"pattern"`
+	Choices     []string  `yaml:"choices"`
+	Min         *int      `yaml:"min"`
+	Max         *int      `yaml:"max"`
+	Required    bool      `yaml:"required"`
+	Secret      bool      `yaml:"secret"`
+	Multiline   bool      `yaml:"multiline"`
+	AskIf       string    `yaml:"ask_if"`
+}
+
+// LoadManifest reads the manifest at the root of dir. It returns nil when there is none.
+func (fr *FileReplacer) LoadManifest(dir string) (*domain.Manifest, error) {
+	data, err := fr.FileSystem.ReadFile(fr.FileSystem.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	return m, nil
+}
+
+// ParseManifest parses and validates a manifest.
+func ParseManifest(data []byte) (*domain.Manifest, error) {
+	var raw yamlManifest
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	m := &domain.Manifest{PathConditions: raw.PathConditions}
+	declared := map[string]bool{}
+	add := func(vars []yamlVariable, section *domain.Section) error {
+		for _, v := range vars {
+			spec := domain.VariableSpec{
+				Name:        strings.TrimSpace(v.Name),
+				Description: v.Description,
+				Type:        v.Type,
+				Pattern:     v.Pattern,
+				Choices:     v.Choices,
+				Min:         v.Min,
+				Max:         v.Max,
+				Required:    v.Required,
+				Secret:      v.Secret,
+				Multiline:   v.Multiline,
+				Section:     section,
+				AskIf:       strings.TrimSpace(v.AskIf),
+			}
+			if spec.Name == "" {
+				return fmt.Errorf("variable #%d has no name", len(m.Variables)+1)
+			}
+			if declared[spec.Name] {
+				return fmt.Errorf("variable %s is declared twice", spec.Name)
+			}
+			if err := yamlValue(&v.Default, &spec.Default); err != nil {
+				return fmt.Errorf("variable %s: default: %w", spec.Name, err)
+			}
+			spec.Default.Key = spec.Name
+			if err := checkSpec(&spec); err != nil {
+				return fmt.Errorf("variable %s: %w", spec.Name, err)
+			}
+			if err := checkAskIf(spec.AskIf, declared); err != nil {
+				return fmt.Errorf("variable %s: %w", spec.Name, err)
+			}
+			declared[spec.Name] = true
+			m.Variables = append(m.Variables, spec)
+		}
+		return nil
+	}
+	if err := add(raw.Variables, nil); err != nil {
+		return nil, err
+	}
+	for i, sec := range raw.Sections {
+		section := &domain.Section{Title: strings.TrimSpace(sec.Title), Description: sec.Description, AskIf: strings.TrimSpace(sec.AskIf)}
+		if section.Title == "" {
+			return nil, fmt.Errorf("section #%d has no title", i+1)
+		}
+		if err := checkAskIf(section.AskIf, declared); err != nil {
+			return nil, fmt.Errorf("section %s: %w", section.Title, err)
+		}
+		m.Sections = append(m.Sections, section)
+		if err := add(sec.Variables, section); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// checkAskIf checks that an ask_if condition parses and only refers to variables
+// declared before it, whose answers are known when it is evaluated.
+func checkAskIf(cond string, declared map[string]bool) error {
+	if cond == "" {
+		return nil
+	}
+	refs, err := ConditionRefs(cond)
+	if err != nil {
+		return fmt.Errorf("ask_if %q: %w", cond, err)
+	}
+	for _, ref := range refs {
+		if !declared[ref] {
+			return fmt.Errorf("ask_if %q: %s is not declared before it", cond, ref)
+		}
+	}
+	return nil
+}
+
+// SkipQuestion reports the ask_if condition, of the section or of the variable,
+// that is false for spec, or "" when the variable is to be asked for.
+func SkipQuestion(spec domain.VariableSpec, lookup func(key string) (string, bool)) string {
+	for _, cond := range []string{sectionAskIf(spec.Section), spec.AskIf} {
+		if cond == "" {
+			continue
+		}
+		// conditions are checked when the manifest is parsed
+		if ok, err := EvalCondition(cond, lookup); err == nil && !ok {
+			return cond
+		}
+	}
+	return ""
+}
+
+func sectionAskIf(s *domain.Section) string {
+	if s == nil {
+		return ""
+	}
+	return s.AskIf
+}
+
+// checkSpec fills in the type and checks that the rest of spec agrees with it.
+func checkSpec(spec *domain.VariableSpec) error {
+	if spec.Type == "" {
+		spec.Type = domain.TypeString
+		if len(spec.Choices) > 0 {
+			spec.Type = domain.TypeEnum
+		}
+	}
+	switch spec.Type {
+	case domain.TypeString, domain.TypeInt, domain.TypeBool:
+		if len(spec.Choices) > 0 {
+			return fmt.Errorf("choices need type enum or list, not %s", spec.Type)
+		}
+	case domain.TypeEnum:
+		if len(spec.Choices) == 0 {
+			return fmt.Errorf("type enum needs choices")
+		}
+	case domain.TypeList:
+	default:
+		return fmt.Errorf("unknown type %q: expected string, int, bool, enum or list", spec.Type)
+	}
+	if (spec.Min != nil || spec.Max != nil) && spec.Type != domain.TypeInt {
+		return fmt.Errorf("min and max need type int, not %s", spec.Type)
+	}
+	if spec.Min != nil && spec.Max != nil && *spec.Min > *spec.Max {
+		return fmt.Errorf("min %d is greater than max %d", *spec.Min, *spec.Max)
+	}
+	if spec.Pattern != "" {
+		if _, err := regexp.Compile(spec.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", spec.Pattern, err)
+		}
+	}
+	if spec.Default.List != nil {
+		if err := CheckList(*spec, spec.Default.List); err != nil {
+			return fmt.Errorf("default: %w", err)
+		}
+		return nil
+	}
+	value, err := CheckValue(*spec, spec.Default.Value)
+	if err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	spec.Default.Value = value
+	return nil
+}
+
+// CheckValue checks a scalar value against spec and returns it in canonical form:
+// integers without surrounding spaces and booleans as true or false. An empty value
+// is accepted; whether it is allowed is up to Required.
+func CheckValue(spec domain.VariableSpec, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch spec.Type {
+	case domain.TypeInt:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("must be an integer, got %q", value)
+		}
+		if spec.Min != nil && n < *spec.Min {
+			return "", fmt.Errorf("must be at least %d, got %d", *spec.Min, n)
+		}
+		if spec.Max != nil && n > *spec.Max {
+			return "", fmt.Errorf("must be at most %d, got %d", *spec.Max, n)
+		}
+		value = strconv.Itoa(n)
+	case domain.TypeBool:
+		b, ok := parseBool(value)
+		if !ok {
+			return "", fmt.Errorf("must be true or false, got %q", value)
+		}
+		value = strconv.FormatBool(b)
+	case domain.TypeEnum:
+		if !containsString(spec.Choices, value) {
+			return "", fmt.Errorf("must be one of %s, got %q", strings.Join(spec.Choices, ", "), value)
+		}
+	case domain.TypeList:
+		// a list typed at the prompt or set as a scalar is comma-separated
+		var items []domain.ListItem
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, domain.ListItem{Value: strings.TrimSpace(item)})
+		}
+		return value, CheckList(spec, items)
+	}
+	return value, checkPattern(spec, value)
+}
+
+// CheckList checks the items of a list value against spec.
+func CheckList(spec domain.VariableSpec, items []domain.ListItem) error {
+	if spec.Type != domain.TypeList {
+		return fmt.Errorf("must be a single value, got a list")
+	}
+	for i, item := range items {
+		if item.Fields != nil {
+			continue
+		}
+		if len(spec.Choices) > 0 && !containsString(spec.Choices, item.Value) {
+			return fmt.Errorf("item %d must be one of %s, got %q", i+1, strings.Join(spec.Choices, ", "), item.Value)
+		}
+		if err := checkPattern(spec, item.Value); err != nil {
+			return fmt.Errorf("item %d %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// checkPattern reports whether the whole value matches the pattern of spec.
+func checkPattern(spec domain.VariableSpec, value string) error {
+	if spec.Pattern == "" {
+		return nil
+	}
+	if !regexp.MustCompile(`^(?:` + spec.Pattern + `)$`).MatchString(value) {
+		return fmt.Errorf("must match %s, got %q", spec.Pattern, value)
+	}
+	return nil
+}
+
+// parseBool accepts the spellings [[#if]] understands for true and false.
+func parseBool(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "y", "on", "1":
+		return true, true
+	case "false", "no", "n", "off", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// RemoveManifest deletes the manifest of dir, once every file has been rendered.
+func (fr *FileReplacer) RemoveManifest(dir string, verbose bool) error {
+	path := fr.FileSystem.Join(dir, ManifestFile)
+	if _, err := fr.FileSystem.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if err := fr.FileSystem.Remove(path); err != nil {
+		return err
+	}
+	if verbose {
+		fmt.Printf("Removed %s\n", ManifestFile)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func TestParseManifest(t *testing.T) {
+	m, err := ParseManifest([]byte(`variables:
+  - name: APP_NAME
+    pattern: "[a-z][a-z0-9-]*"
+    required: true
+  - name: DB
+    choices: [postgres, mysql]
+    default: postgres
+  - name: PORT
+    type: int
+    default: 8080
+  - name: DEBUG
+    type: bool
+    default: yes
+  - name: TARGETS
+    type: list
+    default: [build, test]
+conditional_paths:
+  - path: migrations/
+    when: ne DB "sqlite"
+`))
+	if err != nil {
+		t.Fatalf("ParseManifest failed: %v", err)
+	}
+	if len(m.Variables) != 5 || len(m.PathConditions) != 1 {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	types := []string{domain.TypeString, domain.TypeEnum, domain.TypeInt, domain.TypeBool, domain.TypeList}
+	for i, v := range m.Variables {
+		if v.Type != types[i] {
+			t.Errorf("%s: expected type %s, got %s", v.Name, types[i], v.Type)
+		}
+	}
+	if m.Variables[0].HasDefault() || !m.Variables[0].Required {
+		t.Errorf("APP_NAME: unexpected spec %+v", m.Variables[0])
+	}
+	if got := m.Variables[3].Default.Value; got != "true" {
+		t.Errorf("DEBUG: expected the default to be normalized to true, got %q", got)
+	}
+	if got := m.Variables[4].Default.List; len(got) != 2 || got[1].Value != "test" {
+		t.Errorf("TARGETS: unexpected default %+v", got)
+	}
+
+	errors := map[string]string{
+		"variables: [{description: x}]":                                            "variable #1 has no name",
+		"variables: [{name: A}, {name: A}]":                                        "declared twice",
+		"variables: [{name: A, type: float}]":                                      "unknown type",
+		"variables: [{name: A, type: enum}]":                                       "needs choices",
+		"variables: [{name: A, type: int, choices: [a]}]":                          "choices need type enum or list",
+		"variables: [{name: A, pattern: \"[\"}]":                                   "invalid pattern",
+		"variables: [{name: A, type: int, default: many}]":                         "default: must be an integer",
+		"variables: [{name: A, choices: [a, b], default: c}]":                      "default: must be one of a, b",
+		"variables: [{name: A, min: 1}]":                                           "min and max need type int",
+		"variables: [{name: A, type: int, min: 2, max: 1}]":                        "min 2 is greater than max 1",
+		"variables: [{name: A, type: int, max: 10, default: 11}]":                  "default: must be at most 10, got 11",
+		"variables: [{name: A, default: [a, b]}]":                                  "must be a single value",
+		"variables: [{name: A, type: list, pattern: \"[a-z]+\", default: [a, B]}]": "item 2 must match",
+	}
+	for manifest, want := range errors {
+		if _, err := ParseManifest([]byte(manifest)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected an error containing %q, got %v", manifest, want, err)
+		}
+	}
+}
+
+func TestManifestSections(t *testing.T) {
+	m, err := ParseManifest([]byte(`variables:
+  - name: USE_DB
+    type: bool
+  - name: DB
+    choices: [postgres, mysql]
+    default: postgres
+sections:
+  - title: Database
+    ask_if: USE_DB
+    variables:
+      - name: DB_NAME
+      - name: DB_REPLICAS
+        type: int
+        ask_if: eq DB "postgres"
+`))
+	if err != nil {
+		t.Fatalf("ParseManifest failed: %v", err)
+	}
+	if len(m.Sections) != 1 || len(m.Variables) != 4 || m.Variables[3].Section != m.Sections[0] || m.Variables[1].Section != nil {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+
+	replicas := m.Variables[3]
+	tests := []struct {
+		values map[string]string
+		want   string
+	}{
+		{map[string]string{"USE_DB": "true", "DB": "postgres"}, ""},
+		{map[string]string{"USE_DB": "false", "DB": "postgres"}, "USE_DB"},
+		{map[string]string{"USE_DB": "true", "DB": "mysql"}, `eq DB "postgres"`},
+	}
+	for _, tt := range tests {
+		lookup := func(k string) (string, bool) { v, ok := tt.values[k]; return v, ok }
+		if got := SkipQuestion(replicas, lookup); got != tt.want {
+			t.Errorf("%v: expected %q, got %q", tt.values, tt.want, got)
+		}
+	}
+
+	errors := map[string]string{
+		"sections: [{variables: [{name: A}]}]":                                   "section #1 has no title",
+		"variables: [{name: A, ask_if: B}, {name: B}]":                           "ask_if \"B\": B is not declared before it",
+		"sections: [{title: S, ask_if: A, variables: [{name: A}]}]":              "section S: ask_if \"A\": A is not declared before it",
+		"variables: [{name: A, ask_if: \"eq A\"}]":                               "variable A: ask_if",
+		"variables: [{name: A}]\nsections: [{title: S, variables: [{name: A}]}]": "variable A is declared twice",
+	}
+	for manifest, want := range errors {
+		if _, err := ParseManifest([]byte(manifest)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected an error containing %q, got %v", manifest, want, err)
+		}
+	}
+}
+
+func TestCheckValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    domain.VariableSpec
+		value   string
+		want    string
+		wantErr string
+	}{
+		{"empty is accepted", domain.VariableSpec{Type: domain.TypeInt}, "", "", ""},
+		{"int", domain.VariableSpec{Type: domain.TypeInt}, " 42 ", "42", ""},
+		{"not an int", domain.VariableSpec{Type: domain.TypeInt}, "4x", "", "must be an integer"},
+		{"bool", domain.VariableSpec{Type: domain.TypeBool}, "N", "false", ""},
+		{"int in range", domain.VariableSpec{Type: domain.TypeInt, Min: intPtr(1), Max: intPtr(65535)}, "443", "443", ""},
+		{"int below min", domain.VariableSpec{Type: domain.TypeInt, Min: intPtr(1)}, "0", "", "must be at least 1, got 0"},
+		{"not a bool", domain.VariableSpec{Type: domain.TypeBool}, "maybe", "", "must be true or false"},
+		{"enum", domain.VariableSpec{Type: domain.TypeEnum, Choices: []string{"a", "b"}}, "b", "b", ""},
+		{"not a choice", domain.VariableSpec{Type: domain.TypeEnum, Choices: []string{"a", "b"}}, "c", "", "must be one of a, b"},
+		{"pattern matches the whole value", domain.VariableSpec{Type: domain.TypeString, Pattern: "[a-z]+"}, "abc1", "", "must match"},
+		{"list items", domain.VariableSpec{Type: domain.TypeList, Choices: []string{"a", "b"}}, "a, b", "a, b", ""},
+		{"list item not a choice", domain.VariableSpec{Type: domain.TypeList, Choices: []string{"a", "b"}}, "a,c", "", "item 2 must be one of"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckValue(tt.spec, tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("expected %q, got %q (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestManifestIsNotTemplated(t *testing.T) {
+	dir := writePartialsTree(t, map[string]string{
+		"yankrun.yaml": "variables:\n  - name: NAME\n    description: Name of [[NAME]]\n",
+		"main.go":      "package [[NAME]]\n",
+	})
+	fr := &FileReplacer{FileSystem: &OsFileSystem{}}
+
+	m, err := fr.LoadManifest(dir)
+	if err != nil || m == nil || m.Variables[0].Name != "NAME" {
+		t.Fatalf("LoadManifest: got %+v, %v", m, err)
+	}
+	// without the pattern yankrun.yaml is analyzed like any other file
+	infos, err := fr.AnalyzePlaceholders(dir, nil, "3 mb", "[[", "]]", false)
+	if err != nil || infos["NAME"] == nil || infos["NAME"].Count != 2 {
+		t.Fatalf("expected NAME twice, got %+v, %v", infos["NAME"], err)
+	}
+	infos, err = fr.AnalyzePlaceholders(dir, []string{ManifestIgnorePattern}, "3 mb", "[[", "]]", false)
+	if err != nil {
+		t.Fatalf("AnalyzePlaceholders failed: %v", err)
+	}
+	if infos["NAME"] == nil || infos["NAME"].Count != 1 {
+		t.Errorf("expected NAME once, from main.go only, got %+v", infos["NAME"])
+	}
+
+	if err := fr.RemoveManifest(dir, false); err != nil {
+		t.Fatalf("RemoveManifest failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); !os.IsNotExist(err) {
+		t.Errorf("expected the manifest to be removed, got %v", err)
+	}
+	if m, err := fr.LoadManifest(dir); m != nil || err != nil {
+		t.Errorf("expected no manifest, got %+v, %v", m, err)
+	}
+}
+
+func intPtr(n int) *int { return &n }
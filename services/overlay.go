@@ -0,0 +1,367 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// OverlayFileSystem records writes, removals and renames in memory on top of a
+// base FileSystem that is never modified. Reads see the overlaid state, so the
+// full replacer pipeline can run against it and the planned changes can then be
+// reported with Changes (used by --dry-run).
+type OverlayFileSystem struct {
+	lower FileSystem
+
+	files  map[string]overlayFile
+	dirs   map[string]bool
+	hidden map[string]bool   // base paths (and everything below them) removed from view
+	moved  map[string]string // destination -> original base path
+}
+
+type overlayFile struct {
+	data []byte
+	perm fs.FileMode
+}
+
+// ChangeKind classifies a planned change reported by OverlayFileSystem.Changes.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "A"
+	ChangeModified ChangeKind = "M"
+	ChangeRemoved  ChangeKind = "D"
+	ChangeRenamed  ChangeKind = "R"
+)
+
+// FileChange is a single planned change to a file.
+type FileChange struct {
+	Kind    ChangeKind
+	Path    string
+	OldPath string // set for renames
+	Before  []byte
+	After   []byte
+	OldMode fs.FileMode // permission bits before and after, set for modifications and renames
+	NewMode fs.FileMode
+}
+
+// IsBinary reports whether either side of the change looks like binary content.
+func (c FileChange) IsBinary() bool {
+	return isBinary(c.Before) || isBinary(c.After)
+}
+
+// ModeChanged reports whether the change alters the permission bits of the file.
+func (c FileChange) ModeChanged() bool {
+	return c.OldMode != c.NewMode
+}
+
+func NewOverlayFileSystem(base FileSystem) *OverlayFileSystem {
+	return &OverlayFileSystem{
+		lower:  base,
+		files:  map[string]overlayFile{},
+		dirs:   map[string]bool{},
+		hidden: map[string]bool{},
+		moved:  map[string]string{},
+	}
+}
+
+func (o *OverlayFileSystem) ReadFile(path string) ([]byte, error) {
+	path = filepath.Clean(path)
+	if f, ok := o.files[path]; ok {
+		return append([]byte(nil), f.data...), nil
+	}
+	if o.baseHidden(path) {
+		return nil, notExist("open", path)
+	}
+	return o.lower.ReadFile(path)
+}
+
+func (o *OverlayFileSystem) WriteFile(path string, data []byte, perm fs.FileMode) error {
+	path = filepath.Clean(path)
+	if info, err := o.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+		return notExist("open", path)
+	}
+	if existing, err := o.Stat(path); err == nil {
+		if existing.IsDir() {
+			return &fs.PathError{Op: "open", Path: path, Err: fmt.Errorf("is a directory")}
+		}
+		// like os.WriteFile, perm only applies when creating the file
+		perm = existing.Mode().Perm()
+	}
+	o.files[path] = overlayFile{data: append([]byte(nil), data...), perm: perm}
+	return nil
+}
+
+func (o *OverlayFileSystem) ReadDir(path string) ([]os.FileInfo, error) {
+	path = filepath.Clean(path)
+	entries := map[string]os.FileInfo{}
+	baseErr := error(nil)
+	if !o.baseHidden(path) {
+		infos, err := o.lower.ReadDir(path)
+		baseErr = err
+		for _, info := range infos {
+			if !o.hidden[filepath.Join(path, info.Name())] {
+				entries[info.Name()] = info
+			}
+		}
+	} else {
+		baseErr = notExist("open", path)
+	}
+	overlaid := false
+	for p := range o.dirs {
+		if filepath.Dir(p) == path {
+			entries[filepath.Base(p)] = overlayInfo{name: filepath.Base(p), mode: fs.ModeDir | 0755, dir: true}
+		}
+		if p == path {
+			overlaid = true
+		}
+	}
+	for p, f := range o.files {
+		if filepath.Dir(p) == path {
+			entries[filepath.Base(p)] = overlayInfo{name: filepath.Base(p), size: int64(len(f.data)), mode: f.perm}
+		}
+	}
+	if baseErr != nil && !overlaid {
+		return nil, baseErr
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	infos := make([]os.FileInfo, 0, len(names))
+	for _, name := range names {
+		infos = append(infos, entries[name])
+	}
+	return infos, nil
+}
+
+func (o *OverlayFileSystem) Stat(path string) (os.FileInfo, error) {
+	path = filepath.Clean(path)
+	if f, ok := o.files[path]; ok {
+		return overlayInfo{name: filepath.Base(path), size: int64(len(f.data)), mode: f.perm}, nil
+	}
+	if o.dirs[path] {
+		return overlayInfo{name: filepath.Base(path), mode: fs.ModeDir | 0755, dir: true}, nil
+	}
+	if o.baseHidden(path) {
+		return nil, notExist("stat", path)
+	}
+	return o.lower.Stat(path)
+}
+
+func (o *OverlayFileSystem) EnsureDir(path string) error {
+	path = filepath.Clean(path)
+	if info, err := o.Stat(path); err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("failed to create directory: %s is a file", path)
+		}
+		return nil
+	}
+	if parent := filepath.Dir(path); parent != path {
+		if err := o.EnsureDir(parent); err != nil {
+			return err
+		}
+	}
+	o.dirs[path] = true
+	return nil
+}
+
+func (o *OverlayFileSystem) Join(elem ...string) string {
+	return filepath.Join(elem...)
+}
+
+func (o *OverlayFileSystem) Remove(path string) error {
+	path = filepath.Clean(path)
+	info, err := o.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		children, err := o.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		if len(children) > 0 {
+			return &fs.PathError{Op: "remove", Path: path, Err: fmt.Errorf("directory not empty")}
+		}
+		delete(o.dirs, path)
+	}
+	delete(o.files, path)
+	delete(o.moved, path)
+	o.hidden[path] = true
+	return nil
+}
+
+func (o *OverlayFileSystem) Rename(oldPath, newPath string) error {
+	oldPath, newPath = filepath.Clean(oldPath), filepath.Clean(newPath)
+	info, err := o.Stat(oldPath)
+	if err != nil {
+		return err
+	}
+	if oldPath == newPath {
+		return nil
+	}
+	if !info.IsDir() {
+		data, err := o.ReadFile(oldPath)
+		if err != nil {
+			return err
+		}
+		if err := o.WriteFile(newPath, data, info.Mode().Perm()); err != nil {
+			return err
+		}
+		src := oldPath
+		if orig, ok := o.moved[oldPath]; ok {
+			src = orig
+		}
+		if err := o.Remove(oldPath); err != nil {
+			return err
+		}
+		if _, err := o.lower.Stat(src); err == nil {
+			o.moved[newPath] = src
+		}
+		return nil
+	}
+
+	if err := o.EnsureDir(newPath); err != nil {
+		return err
+	}
+	children, err := o.ReadDir(oldPath)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := o.Rename(filepath.Join(oldPath, child.Name()), filepath.Join(newPath, child.Name())); err != nil {
+			return err
+		}
+	}
+	return o.Remove(oldPath)
+}
+
+// Chmod records the permission bits of a file; directory modes are not tracked.
+func (o *OverlayFileSystem) Chmod(path string, mode fs.FileMode) error {
+	path = filepath.Clean(path)
+	info, err := o.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+	data, err := o.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	o.files[path] = overlayFile{data: data, perm: mode.Perm()}
+	return nil
+}
+
+func (o *OverlayFileSystem) Base(path string) string {
+	return filepath.Base(path)
+}
+
+// Changes returns the planned changes relative to the base file system, sorted by path.
+// Files rewritten with identical content and permission bits are not reported.
+func (o *OverlayFileSystem) Changes() ([]FileChange, error) {
+	var changes []FileChange
+	moveSources := map[string]bool{}
+
+	for path, f := range o.files {
+		src := path
+		if orig, ok := o.moved[path]; ok {
+			src = orig
+			moveSources[orig] = true
+		}
+		before, err := o.lower.ReadFile(src)
+		if err != nil {
+			changes = append(changes, FileChange{Kind: ChangeAdded, Path: path, After: f.data})
+			continue
+		}
+		var oldMode fs.FileMode
+		if info, err := o.lower.Stat(src); err == nil {
+			oldMode = info.Mode().Perm()
+		}
+		switch {
+		case src != path:
+			changes = append(changes, FileChange{Kind: ChangeRenamed, Path: path, OldPath: src, Before: before, After: f.data, OldMode: oldMode, NewMode: f.perm})
+		case !bytes.Equal(before, f.data) || oldMode != f.perm:
+			changes = append(changes, FileChange{Kind: ChangeModified, Path: path, Before: before, After: f.data, OldMode: oldMode, NewMode: f.perm})
+		}
+	}
+
+	removed := map[string]bool{}
+	for path := range o.hidden {
+		if err := o.collectBaseFiles(path, removed); err != nil {
+			return nil, err
+		}
+	}
+	for path := range removed {
+		if _, rewritten := o.files[path]; rewritten || moveSources[path] {
+			continue
+		}
+		before, _ := o.lower.ReadFile(path)
+		changes = append(changes, FileChange{Kind: ChangeRemoved, Path: path, Before: before})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func (o *OverlayFileSystem) collectBaseFiles(path string, into map[string]bool) error {
+	info, err := o.lower.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		into[path] = true
+		return nil
+	}
+	children, err := o.lower.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := o.collectBaseFiles(filepath.Join(path, child.Name()), into); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// baseHidden reports whether path, or one of its parents, was removed from the base view.
+func (o *OverlayFileSystem) baseHidden(path string) bool {
+	for p := path; ; {
+		if o.hidden[p] {
+			return true
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return false
+		}
+		p = parent
+	}
+}
+
+func notExist(op, path string) error {
+	return &fs.PathError{Op: op, Path: path, Err: fs.ErrNotExist}
+}
+
+// overlayInfo describes files and directories that only exist in the overlay.
+type overlayInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+	dir  bool
+}
+
+func (i overlayInfo) Name() string       { return i.name }
+func (i overlayInfo) Size() int64        { return i.size }
+func (i overlayInfo) Mode() fs.FileMode  { return i.mode }
+func (i overlayInfo) ModTime() time.Time { return time.Time{} }
+func (i overlayInfo) IsDir() bool        { return i.dir }
+func (i overlayInfo) Sys() interface{}   { return nil }
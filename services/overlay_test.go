@@ -0,0 +1,137 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func TestOverlayDryRunLeavesDiskUntouched(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":            "package main\n\n// [[APP_NAME]]\nfunc main() {}\n",
+		"unchanged.txt":      "nothing to replace\n",
+		"cmd/[[APP_NAME]]/x": "[[APP_NAME]]\n",
+		"readme.tpl":         "Hello [[APP_NAME]]\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	overlay := NewOverlayFileSystem(&OsFileSystem{})
+	replacer := (&FileReplacer{FileSystem: &OsFileSystem{}}).WithFileSystem(overlay)
+	replacements := domain.InputReplacement{
+		Variables: []domain.Replacement{{Key: "APP_NAME", Value: "demo"}},
+	}
+	if err := replacer.ReplaceInDir(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+	if err := replacer.ProcessTemplateFiles(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ProcessTemplateFiles failed: %v", err)
+	}
+
+	// nothing on disk changed
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("%s should still exist: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s was modified on disk", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "readme")); err == nil {
+		t.Error("readme should not have been written to disk")
+	}
+
+	changes, err := overlay.Changes()
+	if err != nil {
+		t.Fatalf("Changes failed: %v", err)
+	}
+	got := map[string]ChangeKind{}
+	for _, ch := range changes {
+		rel, _ := filepath.Rel(tempDir, ch.Path)
+		got[filepath.ToSlash(rel)] = ch.Kind
+	}
+	expected := map[string]ChangeKind{
+		"main.go":    ChangeModified,
+		"cmd/demo/x": ChangeRenamed,
+		"readme":     ChangeRenamed,
+	}
+	if len(got) != len(expected) {
+		t.Errorf("expected %d changes, got %v", len(expected), got)
+	}
+	for path, kind := range expected {
+		if got[path] != kind {
+			t.Errorf("%s: expected change %s, got %q", path, kind, got[path])
+		}
+	}
+}
+
+func TestOverlayTracksModeChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "run.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	overlay := NewOverlayFileSystem(&OsFileSystem{})
+	if err := overlay.Chmod(path, 0755); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("run.sh should keep its mode on disk, got %v (%v)", info, err)
+	}
+
+	changes, err := overlay.Changes()
+	if err != nil {
+		t.Fatalf("Changes failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Kind != ChangeModified || changes[0].OldMode != 0644 || changes[0].NewMode != 0755 {
+		t.Errorf("expected a mode change from 0644 to 0755, got %+v", changes)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	newText := "one\ntwo\nTHREE\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven"
+
+	expected := `--- a/f
++++ b/f
+@@ -1,6 +1,6 @@
+ one
+ two
+-three
++THREE
+ four
+ five
+ six
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+\ No newline at end of file
+`
+	if got := UnifiedDiff("a/f", "b/f", oldText, newText); got != expected {
+		t.Errorf("diff mismatch. Expected:\n%s\nGot:\n%s", expected, got)
+	}
+
+	if got := UnifiedDiff("a/f", "b/f", "same\n", "same\n"); got != "" {
+		t.Errorf("expected empty diff for identical input, got %q", got)
+	}
+
+	added := UnifiedDiff("/dev/null", "b/new", "", "x\ny\n")
+	if !strings.Contains(added, "@@ -0,0 +1,2 @@\n+x\n+y\n") {
+		t.Errorf("unexpected diff for added file:\n%s", added)
+	}
+}
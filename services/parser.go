@@ -1,24 +1,68 @@
 package services
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
-    "github.com/brasa-ai/yankrun/domain"
+	"github.com/brasa-ai/yankrun/domain"
 
 	"gopkg.in/yaml.v3"
 )
 
 type ReplacementParser interface {
 	Parse(filePath string) (domain.InputReplacement, error)
+	ParseAll(paths []string) (domain.InputReplacement, Sources, error)
+}
+
+// ValueSource is one assignment of a variable: where it came from and the value it set.
+type ValueSource struct {
+	Source string
+	Value  string
+}
+
+// Sources lists, by key, every assignment of each variable in the order they were
+// applied; the last one supplied the value.
+type Sources map[string][]ValueSource
+
+// Add records that source set key to r.
+func (s Sources) Add(key, source string, r domain.Replacement) {
+	value := r.Value
+	if r.List != nil {
+		value = fmt.Sprintf("[%d items]", len(r.List))
+	}
+	s[key] = append(s[key], ValueSource{Source: source, Value: value})
 }
 
 type YAMLJSONParser struct {
 	FileSystem FileSystem
 }
 
+// yamlInput and jsonInput mirror domain.InputReplacement but keep each value raw,
+// so scalars keep their literal text and lists can be told apart from strings.
+type yamlInput struct {
+	Variables []struct {
+		Key   string    `yaml:"key"`
+		Value yaml.Node `yaml:"value"`
+	} `yaml:"variables"`
+	IgnorePath     []string               `yaml:"ignore_patterns"`
+	PathConditions []domain.PathCondition `yaml:"conditional_paths"`
+	Delimiters     []domain.DelimiterRule `yaml:"delimiters"`
+}
+
+type jsonInput struct {
+	Variables []struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	} `json:"variables"`
+	IgnorePath     []string               `json:"ignore_patterns"`
+	PathConditions []domain.PathCondition `json:"conditional_paths"`
+	Delimiters     []domain.DelimiterRule `json:"delimiters"`
+}
+
 func (p *YAMLJSONParser) Parse(filePath string) (domain.InputReplacement, error) {
 	var patterns domain.InputReplacement
 
@@ -30,16 +74,223 @@ func (p *YAMLJSONParser) Parse(filePath string) (domain.InputReplacement, error)
 	ext := strings.ToLower(filepath.Ext(filePath))
 	switch ext {
 	case ".json":
-		err = json.Unmarshal(data, &patterns)
+		var raw jsonInput
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return patterns, err
+		}
+		patterns.IgnorePath = raw.IgnorePath
+		patterns.PathConditions = raw.PathConditions
+		patterns.Delimiters = raw.Delimiters
+		for _, v := range raw.Variables {
+			r := domain.Replacement{Key: v.Key}
+			if err := jsonValue(v.Value, &r); err != nil {
+				return patterns, fmt.Errorf("variable %s: %w", v.Key, err)
+			}
+			patterns.Variables = append(patterns.Variables, r)
+		}
 	case ".yaml", ".yml":
-		err = yaml.Unmarshal(data, &patterns)
+		var raw yamlInput
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return patterns, err
+		}
+		patterns.IgnorePath = raw.IgnorePath
+		patterns.PathConditions = raw.PathConditions
+		patterns.Delimiters = raw.Delimiters
+		for _, v := range raw.Variables {
+			r := domain.Replacement{Key: v.Key}
+			if err := yamlValue(&v.Value, &r); err != nil {
+				return patterns, fmt.Errorf("variable %s: %w", v.Key, err)
+			}
+			patterns.Variables = append(patterns.Variables, r)
+		}
 	default:
 		return patterns, fmt.Errorf("unsupported file format: %s", ext)
 	}
 
+	return patterns, nil
+}
+
+// ParseAll parses the values files at paths and merges them in order with
+// MergeInputs, so later files override earlier ones. A directory stands for its
+// .yaml, .yml and .json files, in name order.
+func (p *YAMLJSONParser) ParseAll(paths []string) (domain.InputReplacement, Sources, error) {
+	var merged domain.InputReplacement
+	sources := Sources{}
+	files, err := p.inputFiles(paths)
 	if err != nil {
-		return patterns, err
+		return merged, nil, err
 	}
+	for _, file := range files {
+		layer, err := p.Parse(file)
+		if err != nil {
+			return merged, nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, r := range layer.Variables {
+			sources.Add(r.Key, file, r)
+		}
+		merged = MergeInputs(merged, layer)
+	}
+	return merged, sources, nil
+}
 
-	return patterns, nil
+// inputFiles expands the directories among paths into their values files.
+func (p *YAMLJSONParser) inputFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := p.FileSystem.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := p.FileSystem.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, e := range entries {
+			switch strings.ToLower(filepath.Ext(e.Name())) {
+			case ".json", ".yaml", ".yml":
+				if !e.IsDir() {
+					names = append(names, e.Name())
+				}
+			}
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%s: no .yaml, .yml or .json values files", path)
+		}
+		for _, name := range names {
+			files = append(files, p.FileSystem.Join(path, name))
+		}
+	}
+	return files, nil
+}
+
+// MergeInputs returns base with layer applied on top. A variable of layer replaces
+// the one with the same key in base, keeping its position; new variables are added
+// at the end. Ignore patterns are added unless already present, and conditional
+// paths and delimiter rules are appended, so later delimiter rules take precedence.
+func MergeInputs(base, layer domain.InputReplacement) domain.InputReplacement {
+	merged := domain.InputReplacement{
+		Variables:      append([]domain.Replacement{}, base.Variables...),
+		IgnorePath:     append([]string{}, base.IgnorePath...),
+		PathConditions: append(append([]domain.PathCondition{}, base.PathConditions...), layer.PathConditions...),
+		Delimiters:     append(append([]domain.DelimiterRule{}, base.Delimiters...), layer.Delimiters...),
+	}
+	index := map[string]int{}
+	for i, r := range merged.Variables {
+		index[r.Key] = i
+	}
+	for _, r := range layer.Variables {
+		if i, ok := index[r.Key]; ok {
+			merged.Variables[i] = r
+			continue
+		}
+		index[r.Key] = len(merged.Variables)
+		merged.Variables = append(merged.Variables, r)
+	}
+	for _, pattern := range layer.IgnorePath {
+		if !containsString(merged.IgnorePath, pattern) {
+			merged.IgnorePath = append(merged.IgnorePath, pattern)
+		}
+	}
+	return merged
+}
+
+// yamlValue fills r.Value for scalars or r.List for sequences of scalars or maps.
+func yamlValue(node *yaml.Node, r *domain.Replacement) error {
+	switch node.Kind {
+	case 0:
+		return nil
+	case yaml.ScalarNode:
+		if node.Tag != "!!null" {
+			r.Value = node.Value
+		}
+		return nil
+	case yaml.SequenceNode:
+		r.List = []domain.ListItem{}
+		for _, item := range node.Content {
+			switch item.Kind {
+			case yaml.ScalarNode:
+				r.List = append(r.List, domain.ListItem{Value: item.Value})
+			case yaml.MappingNode:
+				fields := map[string]string{}
+				for i := 0; i+1 < len(item.Content); i += 2 {
+					if item.Content[i+1].Kind != yaml.ScalarNode {
+						return fmt.Errorf("list item field %s must be a scalar", item.Content[i].Value)
+					}
+					fields[item.Content[i].Value] = item.Content[i+1].Value
+				}
+				r.List = append(r.List, domain.ListItem{Fields: fields})
+			default:
+				return fmt.Errorf("list items must be scalars or maps")
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("value must be a scalar or a list")
+}
+
+// jsonValue is the JSON counterpart of yamlValue. Numbers and booleans keep their literal text.
+func jsonValue(raw json.RawMessage, r *domain.Replacement) error {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) == 0 || string(raw) == "null":
+		return nil
+	case raw[0] == '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		r.List = []domain.ListItem{}
+		for _, item := range items {
+			item = bytes.TrimSpace(item)
+			if len(item) > 0 && item[0] == '{' {
+				var obj map[string]json.RawMessage
+				if err := json.Unmarshal(item, &obj); err != nil {
+					return err
+				}
+				fields := map[string]string{}
+				for k, v := range obj {
+					s, err := jsonScalar(v)
+					if err != nil {
+						return fmt.Errorf("list item field %s: %w", k, err)
+					}
+					fields[k] = s
+				}
+				r.List = append(r.List, domain.ListItem{Fields: fields})
+				continue
+			}
+			s, err := jsonScalar(item)
+			if err != nil {
+				return err
+			}
+			r.List = append(r.List, domain.ListItem{Value: s})
+		}
+		return nil
+	}
+	s, err := jsonScalar(raw)
+	if err != nil {
+		return err
+	}
+	r.Value = s
+	return nil
+}
+
+func jsonScalar(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) == 0 || string(raw) == "null":
+		return "", nil
+	case raw[0] == '"':
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	case raw[0] == '[' || raw[0] == '{':
+		return "", fmt.Errorf("must be a scalar")
+	}
+	return string(raw), nil
 }
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func TestParseListValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"yaml", "values.yaml", `
+variables:
+  - key: APP
+    value: demo
+  - key: PORT
+    value: 8080
+  - key: REGIONS
+    value: [us, eu]
+  - key: SERVICES
+    value:
+      - name: api
+        port: 8080
+      - name: web
+        port: 3000
+ignore_patterns: ["*.lock"]
+conditional_paths:
+  - path: helm/
+    when: K8S
+delimiters:
+  - path: "*.sh"
+    start: "<%"
+    end: "%>"
+`},
+		{"json", "values.json", `{
+  "variables": [
+    {"key": "APP", "value": "demo"},
+    {"key": "PORT", "value": 8080},
+    {"key": "REGIONS", "value": ["us", "eu"]},
+    {"key": "SERVICES", "value": [{"name": "api", "port": 8080}, {"name": "web", "port": 3000}]}
+  ],
+  "ignore_patterns": ["*.lock"],
+  "conditional_paths": [{"path": "helm/", "when": "K8S"}],
+  "delimiters": [{"path": "*.sh", "start": "<%", "end": "%>"}]
+}`},
+	}
+
+	expected := []domain.Replacement{
+		{Key: "APP", Value: "demo"},
+		{Key: "PORT", Value: "8080"},
+		{Key: "REGIONS", List: []domain.ListItem{{Value: "us"}, {Value: "eu"}}},
+		{Key: "SERVICES", List: []domain.ListItem{
+			{Fields: map[string]string{"name": "api", "port": "8080"}},
+			{Fields: map[string]string{"name": "web", "port": "3000"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			parser := &YAMLJSONParser{FileSystem: &OsFileSystem{}}
+			got, err := parser.Parse(path)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if !reflect.DeepEqual(got.Variables, expected) {
+				t.Errorf("expected %+v, got %+v", expected, got.Variables)
+			}
+			if !reflect.DeepEqual(got.IgnorePath, []string{"*.lock"}) {
+				t.Errorf("unexpected ignore patterns %v", got.IgnorePath)
+			}
+			if !reflect.DeepEqual(got.PathConditions, []domain.PathCondition{{Path: "helm/", When: "K8S"}}) {
+				t.Errorf("unexpected conditional paths %v", got.PathConditions)
+			}
+			if !reflect.DeepEqual(got.Delimiters, []domain.DelimiterRule{{Path: "*.sh", Start: "<%", End: "%>"}}) {
+				t.Errorf("unexpected delimiters %v", got.Delimiters)
+			}
+		})
+	}
+}
+
+func TestParseAllLayers(t *testing.T) {
+	dir := writePartialsTree(t, map[string]string{
+		"defaults/20-team.json": `{"variables": [{"key": "APP", "value": "team"}, {"key": "REGIONS", "value": ["us"]}], "ignore_patterns": ["*.lock", "dist/"]}`,
+		"defaults/10-org.yaml":  "variables:\n  - {key: APP, value: org}\n  - {key: ORG, value: acme}\nignore_patterns: [\"*.lock\"]\ndelimiters: [{path: \"*.sh\", start: \"<%\", end: \"%>\"}]\n",
+		"defaults/README.md":    "not a values file",
+		"project.yaml":          "variables:\n  - {key: REGIONS, value: [us, eu]}\n  - {key: APP, value: demo}\n  - {key: PORT, value: 8080}\ndelimiters: [{path: \"*.sh\", start: \"{{\", end: \"}}\"}]\n",
+	})
+	parser := &YAMLJSONParser{FileSystem: &OsFileSystem{}}
+	defaults, project := filepath.Join(dir, "defaults"), filepath.Join(dir, "project.yaml")
+
+	got, sources, err := parser.ParseAll([]string{defaults, project})
+	if err != nil {
+		t.Fatalf("ParseAll failed: %v", err)
+	}
+	expected := []domain.Replacement{
+		{Key: "APP", Value: "demo"},
+		{Key: "ORG", Value: "acme"},
+		{Key: "REGIONS", List: []domain.ListItem{{Value: "us"}, {Value: "eu"}}},
+		{Key: "PORT", Value: "8080"},
+	}
+	if !reflect.DeepEqual(got.Variables, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got.Variables)
+	}
+	if !reflect.DeepEqual(got.IgnorePath, []string{"*.lock", "dist/"}) {
+		t.Errorf("unexpected ignore patterns %v", got.IgnorePath)
+	}
+	if len(got.Delimiters) != 2 || got.Delimiters[1].Start != "{{" {
+		t.Errorf("expected the project delimiters last, got %v", got.Delimiters)
+	}
+
+	wantSources := []ValueSource{
+		{Source: filepath.Join(defaults, "10-org.yaml"), Value: "org"},
+		{Source: filepath.Join(defaults, "20-team.json"), Value: "team"},
+		{Source: project, Value: "demo"},
+	}
+	if !reflect.DeepEqual(sources["APP"], wantSources) {
+		t.Errorf("expected sources %+v, got %+v", wantSources, sources["APP"])
+	}
+	if s := sources["REGIONS"]; len(s) != 2 || s[1].Value != "[2 items]" {
+		t.Errorf("unexpected REGIONS sources %+v", s)
+	}
+
+	if got, _, err := parser.ParseAll(nil); err != nil || len(got.Variables) != 0 {
+		t.Errorf("expected nothing without paths, got %+v (%v)", got, err)
+	}
+	for _, paths := range [][]string{{filepath.Join(dir, "missing.yaml")}, {t.TempDir()}} {
+		if _, _, err := parser.ParseAll(paths); err == nil {
+			t.Errorf("%v: expected an error", paths)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Partials are shared fragments kept in the _partials directory of the template and
+// included with [[> name]]:
+//
+//	[[> license-header]]
+//	package main
+//
+// The name is a slash-separated path inside _partials (a leading "_partials/" is
+// optional) and the file extension may be left out. Partials are rendered with the
+// values, loop variables and delimiters of the file that includes them and may include
+// other partials, as long as there is no cycle. Clone and generate leave the _partials
+// directory itself out of templating and RemovePartials removes it from their output. A
+// tree templated in place keeps it, as it may hold unrelated partials (SCSS, Hugo), and
+// only leaves it out when UsesPartials finds an include.
+
+// PartialsDir is the directory, at the root of a template, that holds the partials.
+const PartialsDir = "_partials"
+
+// PartialsIgnorePattern keeps the partials themselves out of analysis and replacement,
+// when added to the ignore patterns.
+const PartialsIgnorePattern = "/" + PartialsDir + "/"
+
+// partialSet resolves and parses the partials below root, caching each one by path
+// and delimiters.
+type partialSet struct {
+	fs    FileSystem
+	root  string
+	cache map[string][]templateNode
+}
+
+func newPartialSet(fs FileSystem, root string) *partialSet {
+	return &partialSet{fs: fs, root: root, cache: map[string][]templateNode{}}
+}
+
+// resolve returns the path of the partial called name.
+func (ps *partialSet) resolve(name string) (string, bool) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), PartialsDir+"/")
+	if name == "" || strings.HasPrefix(name, "/") {
+		return "", false
+	}
+	for _, seg := range strings.Split(name, "/") {
+		if seg == ".." {
+			return "", false
+		}
+	}
+	path := ps.fs.Join(ps.root, PartialsDir, filepath.FromSlash(name))
+	if info, err := ps.fs.Stat(path); err == nil && !info.IsDir() {
+		return path, true
+	}
+	// without extension: the first file whose name is name plus an extension
+	entries, err := ps.fs.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return "", false
+	}
+	var matches []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())) == filepath.Base(path) {
+			matches = append(matches, e.Name())
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+	sort.Strings(matches)
+	return ps.fs.Join(filepath.Dir(path), matches[0]), true
+}
+
+// expand resolves the partials used by nodes, recursively, so they can be rendered
+// and analyzed like the rest of the file. stack holds the partials being expanded.
+func (ps *partialSet) expand(file string, nodes []templateNode, startDelim, endDelim string, stack []string) error {
+	for _, node := range nodes {
+		var err error
+		switch n := node.(type) {
+		case *ifNode:
+			if err = ps.expand(file, n.then, startDelim, endDelim, stack); err == nil {
+				err = ps.expand(file, n.els, startDelim, endDelim, stack)
+			}
+		case *eachNode:
+			err = ps.expand(file, n.body, startDelim, endDelim, stack)
+		case *partialNode:
+			err = ps.load(file, n, startDelim, endDelim, stack)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ps *partialSet) load(file string, n *partialNode, startDelim, endDelim string, stack []string) error {
+	path, ok := ps.resolve(n.name)
+	if !ok {
+		return &TemplateError{File: file, Line: n.line, Column: n.col, Msg: fmt.Sprintf("partial %q not found in %s", n.name, PartialsDir)}
+	}
+	for i, p := range stack {
+		if p == path {
+			var chain []string
+			for _, s := range append(stack[i:], path) {
+				chain = append(chain, ps.name(s))
+			}
+			return &TemplateError{File: file, Line: n.line, Column: n.col, Msg: "partials form a cycle: " + strings.Join(chain, " -> ")}
+		}
+	}
+
+	key := path + "\x00" + startDelim + "\x00" + endDelim
+	if body, ok := ps.cache[key]; ok {
+		n.file, n.body = path, body
+		return nil
+	}
+	content, err := ps.fs.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	body, err := parseTemplate(path, string(content), startDelim, endDelim)
+	if err != nil {
+		return err
+	}
+	if err := ps.expand(path, body, startDelim, endDelim, append(stack, path)); err != nil {
+		return err
+	}
+	ps.cache[key] = body
+	n.file, n.body = path, body
+	return nil
+}
+
+// name returns the path of a partial relative to the partials directory, for messages.
+func (ps *partialSet) name(path string) string {
+	rel, err := filepath.Rel(ps.fs.Join(ps.root, PartialsDir), path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// UsesPartials reports whether a file below dir, outside of _partials, includes a partial.
+func (fr *FileReplacer) UsesPartials(dir string, ignorePatterns []string, fileSizeLimit string, startDelim string, endDelim string) (bool, error) {
+	fileSizeInBytes, err := fr.stringToBytes(fileSizeLimit)
+	if err != nil {
+		return false, err
+	}
+	ignore := NewIgnoreMatcher(append(append([]string{}, ignorePatterns...), PartialsIgnorePattern))
+	partials := newPartialSet(fr.FileSystem, dir)
+	if err := fr.walkAndAnalyze(dir, ignore, fileSizeInBytes, startDelim, endDelim, partials, map[string]*PlaceholderInfo{}, false); err != nil {
+		return false, err
+	}
+	return len(partials.cache) > 0, nil
+}
+
+// RemovePartials deletes the partials directory of dir, once every file has been rendered.
+func (fr *FileReplacer) RemovePartials(dir string, verbose bool) error {
+	path := fr.FileSystem.Join(dir, PartialsDir)
+	info, err := fr.FileSystem.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return nil
+	}
+	if err := fr.removeAll(path); err != nil {
+		return err
+	}
+	if verbose {
+		fmt.Printf("Removed %s\n", PartialsDir)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func writePartialsTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestRenderPartials(t *testing.T) {
+	dir := writePartialsTree(t, map[string]string{
+		"_partials/license-header.txt": "// Copyright [[YEAR]] [[ORG:toUpperCase]]\n[[> notice]]",
+		"_partials/notice":             "// Licensed under MIT\n",
+		"_partials/ci/step.yaml":       "- run: make [[S]]\n",
+	})
+	scope := &renderScope{values: map[string]string{"YEAR": "2026", "ORG": "acme", "TARGETS": "build,test"}}
+	scope.partials = newPartialSet(&OsFileSystem{}, dir)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"standalone include", "[[> license-header]]\npackage main\n", "// Copyright 2026 ACME\n// Licensed under MIT\npackage main\n"},
+		{"prefixed name and extension", "[[> _partials/notice]][[> notice]]", "// Licensed under MIT\n// Licensed under MIT\n"},
+		{"loop variables", "steps:\n[[#each TARGETS as S]]\n[[> ci/step.yaml]]\n[[/each]]\n", "steps:\n- run: make build\n- run: make test\n"},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := fr.renderText("main.go", tt.input, scope, "[[", "]]")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	// Without a partial set, for instance in file names, includes are left alone
+	got, _, err := fr.renderText("main.go", "[[> notice]]", &renderScope{}, "[[", "]]")
+	if err != nil || got != "[[> notice]]" {
+		t.Errorf("expected the include to be kept, got %q (%v)", got, err)
+	}
+}
+
+func TestRenderPartialErrors(t *testing.T) {
+	dir := writePartialsTree(t, map[string]string{
+		"_partials/a":   "A [[> b]]",
+		"_partials/b":   "B\n[[> a]]",
+		"_partials/bad": "[[#if X]]",
+		"outside.txt":   "secret",
+	})
+	scope := &renderScope{partials: newPartialSet(&OsFileSystem{}, dir)}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x\n[[> missing]]", `main.go:2:1: partial "missing" not found in _partials`},
+		{"[[> ../outside.txt]]", `partial "../outside.txt" not found`},
+		{"[[>]]", "main.go:1:1: missing partial name in [[>]]"},
+		{"[[> a]]", ":2:1: partials form a cycle: a -> b -> a"},
+		{"[[> bad]]", filepath.Join(dir, "_partials", "bad") + ":1:1: unclosed [[#if X]]"},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		_, _, err := fr.renderText("main.go", tt.input, scope, "[[", "]]")
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestPartialsInDirectory(t *testing.T) {
+	dir := writePartialsTree(t, map[string]string{
+		"_partials/header": "# [[APP_NAME]] by [[ORG|acme]]\n",
+		"README.md":        "[[> header]]\nDocs\n",
+		"cmd/main.go.tpl":  "[[> header]]\npackage main\n",
+	})
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	if uses, err := replacer.UsesPartials(dir, nil, "3 mb", "[[", "]]"); err != nil || !uses {
+		t.Fatalf("expected the tree to use partials, got %v, %v", uses, err)
+	}
+	ignore := []string{PartialsIgnorePattern}
+	infos, err := replacer.AnalyzePlaceholders(dir, ignore, "3 mb", "[[", "]]", false)
+	if err != nil {
+		t.Fatalf("AnalyzePlaceholders failed: %v", err)
+	}
+	// counted once per include, the ignored partial itself is not analyzed
+	if a := infos["APP_NAME"]; a == nil || a.Count != 2 {
+		t.Errorf("unexpected APP_NAME info %+v", a)
+	}
+	if o := infos["ORG"]; o == nil || !o.HasDefault || o.Default != "acme" {
+		t.Errorf("unexpected ORG info %+v", o)
+	}
+
+	repl := domain.InputReplacement{Variables: []domain.Replacement{{Key: "APP_NAME", Value: "demo"}}, IgnorePath: ignore}
+	if err := replacer.ReplaceInDir(dir, repl, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+	if err := replacer.ProcessTemplateFiles(dir, repl, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ProcessTemplateFiles failed: %v", err)
+	}
+	if err := replacer.RemovePartials(dir, false); err != nil {
+		t.Fatalf("RemovePartials failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"README.md":   "# demo by acme\nDocs\n",
+		"cmd/main.go": "# demo by acme\npackage main\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, PartialsDir)); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got %v", PartialsDir, err)
+	}
+}
+
+func TestUsesPartialsWithoutIncludes(t *testing.T) {
+	dir := writePartialsTree(t, map[string]string{
+		"_partials/_buttons.scss": ".btn { color: [[COLOR]]; }\n",
+		"main.scss":               "@use 'partials/buttons';\n",
+	})
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	if uses, err := replacer.UsesPartials(dir, nil, "3 mb", "[[", "]]"); err != nil || uses {
+		t.Errorf("expected no partials in use, got %v, %v", uses, err)
+	}
+}
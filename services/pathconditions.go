@@ -0,0 +1,189 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+// Conditional paths drop whole files or directories from the output:
+//
+//	conditional_paths:
+//	  - path: .github/workflows/
+//	    when: ne CI "none"
+//	  - path: helm/
+//	    when: K8S
+//
+// Paths use gitignore syntax and conditions the same syntax as [[#if ...]] blocks.
+// A path matched by several rules is kept only when all of their conditions hold.
+// Negated paths ("!helm/") are rejected; negate the condition instead ("not K8S").
+
+type pathRule struct {
+	rule ignoreRule
+	when string
+	keep bool
+}
+
+func compilePathRules(conditions []domain.PathCondition, scope *renderScope) ([]pathRule, error) {
+	var rules []pathRule
+	for _, c := range conditions {
+		if err := checkPathCondition(c); err != nil {
+			return nil, err
+		}
+		r, ok := compileIgnoreRule(c.Path)
+		if !ok {
+			return nil, fmt.Errorf("conditional path %q: invalid pattern", c.Path)
+		}
+		keep, err := EvalCondition(c.When, scope.lookup)
+		if err != nil {
+			return nil, fmt.Errorf("conditional path %q: %w", c.Path, err)
+		}
+		rules = append(rules, pathRule{rule: r, when: c.When, keep: keep})
+	}
+	return rules, nil
+}
+
+// checkPathCondition rejects negated paths, which would otherwise apply the condition
+// to exactly the paths the user meant to leave out.
+func checkPathCondition(c domain.PathCondition) error {
+	if strings.HasPrefix(c.Path, "!") {
+		return fmt.Errorf("conditional path %q: negated paths are not supported, negate the condition instead (when: not ...)", c.Path)
+	}
+	return nil
+}
+
+// excludedBy returns the first rule whose condition excludes rel, if any.
+func excludedBy(rules []pathRule, rel string, isDir bool) (pathRule, bool) {
+	for _, r := range rules {
+		if r.keep || (r.rule.dirOnly && !isDir) {
+			continue
+		}
+		if r.rule.re.MatchString(rel) {
+			return r, true
+		}
+	}
+	return pathRule{}, false
+}
+
+// PathConditionRefs returns the variables referenced by the conditions, so they can be
+// reported and prompted for together with the placeholders.
+func PathConditionRefs(conditions []domain.PathCondition) ([]string, error) {
+	var refs []string
+	for _, c := range conditions {
+		if err := checkPathCondition(c); err != nil {
+			return nil, err
+		}
+		r, err := ConditionRefs(c.When)
+		if err != nil {
+			return nil, fmt.Errorf("conditional path %q: %w", c.Path, err)
+		}
+		refs = append(refs, r...)
+	}
+	return refs, nil
+}
+
+// excludedPath is a file or directory excluded by a conditional path.
+type excludedPath struct {
+	path string
+	when string // the condition that is false
+}
+
+// removeExcludedPaths deletes the files and directories excluded by the conditional
+// paths in replacements, then prunes the directories left empty.
+func (fr *FileReplacer) removeExcludedPaths(dir string, ignore *IgnoreMatcher, replacements domain.InputReplacement, verbose bool) error {
+	excluded, err := fr.excludedPaths(dir, ignore, replacements)
+	if err != nil {
+		return err
+	}
+	return fr.removePaths(dir, excluded, verbose)
+}
+
+// excludedPaths returns the files and directories excluded by the conditional paths in
+// replacements, without the contents of excluded directories.
+func (fr *FileReplacer) excludedPaths(dir string, ignore *IgnoreMatcher, replacements domain.InputReplacement) ([]excludedPath, error) {
+	if len(replacements.PathConditions) == 0 {
+		return nil, nil
+	}
+	rules, err := compilePathRules(replacements.PathConditions, newRenderScope(replacements))
+	if err != nil {
+		return nil, err
+	}
+
+	var excluded []excludedPath
+	err = fr.walkEntries(dir, ignore, func(path, rel string, info os.FileInfo) error {
+		if isExcluded(excluded, path) {
+			return nil
+		}
+		if r, ok := excludedBy(rules, rel, info.IsDir()); ok {
+			excluded = append(excluded, excludedPath{path: path, when: r.when})
+		}
+		return nil
+	})
+	return excluded, err
+}
+
+// isExcluded reports whether path is one of excluded or inside one of them.
+func isExcluded(excluded []excludedPath, path string) bool {
+	for _, e := range excluded {
+		if path == e.path || strings.HasPrefix(path, e.path+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// removePaths deletes the excluded paths, then prunes the directories left empty.
+func (fr *FileReplacer) removePaths(dir string, excluded []excludedPath, verbose bool) error {
+	for _, e := range excluded {
+		if err := fr.removeAll(e.path); err != nil {
+			return err
+		}
+		if err := fr.pruneEmptyParents(dir, e.path); err != nil {
+			return err
+		}
+		if verbose {
+			fmt.Printf("Removed %s (%s is false)\n", e.path, e.when)
+		}
+	}
+	return nil
+}
+
+func (fr *FileReplacer) removeAll(path string) error {
+	info, err := fr.FileSystem.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		children, err := fr.FileSystem.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if err := fr.removeAll(fr.FileSystem.Join(path, child.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return fr.FileSystem.Remove(path)
+}
+
+// pruneEmptyParents removes the now empty parent directories of path below root.
+func (fr *FileReplacer) pruneEmptyParents(root, path string) error {
+	root = filepath.Clean(root)
+	for parent := filepath.Dir(path); parent != root && strings.HasPrefix(parent, root+string(filepath.Separator)); parent = filepath.Dir(parent) {
+		children, err := fr.FileSystem.ReadDir(parent)
+		if err != nil {
+			return err
+		}
+		if len(children) > 0 {
+			return nil
+		}
+		if err := fr.FileSystem.Remove(parent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func TestConditionalPathsRemoveExcludedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	files := []string{
+		"main.go",
+		".github/workflows/ci.yml",
+		".github/CODEOWNERS",
+		"deploy/helm/chart.yaml",
+		"deploy/helm/templates/app.yaml",
+		"docs/db.md",
+		"docs/intro.md",
+		"migrations/001.sql",
+	}
+	for _, name := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("app: [[APP]]\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	replacements := domain.InputReplacement{
+		Variables: []domain.Replacement{
+			{Key: "APP", Value: "demo"},
+			{Key: "CI", Value: "none"},
+			{Key: "USE_DB", Value: "true"},
+		},
+		PathConditions: []domain.PathCondition{
+			{Path: ".github/workflows/", When: `ne CI "none"`},
+			{Path: "helm/", When: "K8S"},
+			{Path: "docs/db.md", When: "USE_DB"},
+			{Path: "migrations/", When: "USE_DB"},
+		},
+	}
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	if err := replacer.ReplaceInDir(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+
+	var got []string
+	err := filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(tempDir, path)
+		if rel != "." {
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	sort.Strings(got)
+	// empty .github/workflows and deploy/ are pruned, .github stays for CODEOWNERS
+	expected := []string{".github", ".github/CODEOWNERS", "docs", "docs/db.md", "docs/intro.md", "main.go", "migrations", "migrations/001.sql"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(tempDir, "main.go"))
+	if string(content) != "app: demo\n" {
+		t.Errorf("main.go mismatch, got %q", string(content))
+	}
+}
+
+func TestConditionalPathsErrors(t *testing.T) {
+	replacements := domain.InputReplacement{
+		PathConditions: []domain.PathCondition{{Path: "helm/", When: "eq K8S"}},
+	}
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	err := replacer.ReplaceInDir(t.TempDir(), replacements, "3 mb", "[[", "]]", false)
+	if err == nil || !strings.Contains(err.Error(), `conditional path "helm/"`) {
+		t.Errorf("expected conditional path error, got %v", err)
+	}
+
+	replacements.PathConditions = []domain.PathCondition{{Path: "!helm/", When: "K8S"}}
+	err = replacer.ReplaceInDir(t.TempDir(), replacements, "3 mb", "[[", "]]", false)
+	if err == nil || !strings.Contains(err.Error(), `conditional path "!helm/": negated paths are not supported`) {
+		t.Errorf("expected a negated path error, got %v", err)
+	}
+	if _, err := PathConditionRefs(replacements.PathConditions); err == nil {
+		t.Error("expected PathConditionRefs to reject the negated path")
+	}
+
+	refs, err := PathConditionRefs([]domain.PathCondition{{Path: "a", When: "and USE_DB (eq CI \"github\")"}})
+	if err != nil || strings.Join(refs, ",") != "USE_DB,CI" {
+		t.Errorf("unexpected refs %v (%v)", refs, err)
+	}
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholder grammar (the text between the delimiters):
+//
+//	placeholder = key [ "|" default | "!" ] { ":" transform }
+//	default     = quoted | raw text up to the next ":"
+//	transform   = name [ "(" [ arg { "," arg } ] ")" ]
+//	arg         = quoted | raw
+//
+// "|" and "!" are only modifiers after a key such as APP_NAME or S.name. A tag that
+// uses them after anything else, like the shell test [[ -n "$A" || -z "$B" ]], is
+// left as text.
+//
+// Quoted strings use " or ' and understand the \" \' \\ \n \r and \t escapes; any
+// other backslash is kept as is, so regular expressions can be quoted unchanged.
+// Blanks around a quoted argument are ignored. Raw arguments are taken verbatim up
+// to the next "," or ")" outside parentheses, so "gsub( ,-)" still replaces spaces
+// and "replaceRegex((\w+)-(\w+),$2)" needs no quoting. A backslash stops the next
+// character from ending the argument and is kept, except in "\,", which is a comma.
+
+// placeholderExpr is a parsed placeholder.
+type placeholderExpr struct {
+	key        placeholderKey
+	transforms []transformCall
+}
+
+// placeholderKey is the base key of a placeholder with its inline modifiers:
+// KEY|default supplies a default value and KEY! marks the key as required.
+type placeholderKey struct {
+	name       string
+	def        string
+	hasDefault bool
+	required   bool
+}
+
+// transformCall is one transformation in a placeholder chain.
+type transformCall struct {
+	name   string
+	args   []transformArg
+	parens bool   // written with parentheses, even when empty
+	text   string // source text, used in error messages
+}
+
+type transformArg struct {
+	value  string
+	quoted bool
+}
+
+// placeholderSyntaxError reports a syntax error at a byte offset of the placeholder text.
+type placeholderSyntaxError struct {
+	offset int
+	msg    string
+}
+
+func (e *placeholderSyntaxError) Error() string {
+	return e.msg
+}
+
+// placeholderKeyPattern is the form a key needs for "|" and "!" to be read as modifiers.
+var placeholderKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// isLiteralTag reports whether inner uses "|" or "!" after something that is not a key.
+func isLiteralTag(inner string) bool {
+	keyEnd := strings.IndexAny(inner, "|:")
+	if keyEnd == -1 {
+		keyEnd = len(inner)
+	}
+	name := inner[:keyEnd]
+	modified := keyEnd < len(inner) && inner[keyEnd] == '|'
+	if strings.HasSuffix(name, "!") {
+		name, modified = strings.TrimSuffix(name, "!"), true
+	}
+	return modified && !placeholderKeyPattern.MatchString(strings.TrimSpace(name))
+}
+
+// parsePlaceholderExpr parses the text between the delimiters. On a syntax error
+// the returned expression still holds everything parsed before it, including the key.
+func parsePlaceholderExpr(src string) (*placeholderExpr, error) {
+	p := &placeholderParser{src: src}
+	expr := &placeholderExpr{}
+
+	keyEnd := strings.IndexAny(src, "|:")
+	if keyEnd == -1 {
+		keyEnd = len(src)
+	}
+	expr.key.name = src[:keyEnd]
+	p.pos = keyEnd
+	if strings.HasSuffix(expr.key.name, "!") {
+		expr.key.name = strings.TrimSpace(strings.TrimSuffix(expr.key.name, "!"))
+		expr.key.required = true
+	}
+	if p.peek() == '|' {
+		p.pos++
+		expr.key.name = strings.TrimSpace(expr.key.name)
+		def, err := p.defaultValue()
+		if err != nil {
+			return expr, err
+		}
+		expr.key.def, expr.key.hasDefault = def, true
+	}
+
+	for p.pos < len(src) {
+		if p.peek() != ':' {
+			return expr, p.errorf(p.pos, "unexpected %q", string(p.peek()))
+		}
+		p.pos++
+		call, err := p.transform()
+		if err != nil {
+			return expr, err
+		}
+		expr.transforms = append(expr.transforms, call)
+	}
+	return expr, nil
+}
+
+type placeholderParser struct {
+	src string
+	pos int
+}
+
+func (p *placeholderParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *placeholderParser) errorf(offset int, format string, args ...interface{}) error {
+	return &placeholderSyntaxError{offset: offset, msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *placeholderParser) skipBlanks() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *placeholderParser) defaultValue() (string, error) {
+	start := p.pos
+	p.skipBlanks()
+	if c := p.peek(); c == '"' || c == '\'' {
+		value, err := p.quoted()
+		if err != nil {
+			return "", err
+		}
+		p.skipBlanks()
+		return value, nil
+	}
+	p.pos = start
+	end := strings.IndexByte(p.src[p.pos:], ':')
+	if end == -1 {
+		end = len(p.src) - p.pos
+	}
+	value := p.src[p.pos : p.pos+end]
+	p.pos += end
+	return value, nil
+}
+
+func (p *placeholderParser) transform() (transformCall, error) {
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != '(' && p.src[p.pos] != ':' {
+		p.pos++
+	}
+	call := transformCall{name: strings.TrimSpace(p.src[start:p.pos])}
+	if call.name == "" {
+		return call, p.errorf(start, "missing transformation name")
+	}
+	if p.peek() == '(' {
+		open := p.pos
+		p.pos++
+		call.parens = true
+		args, err := p.args(open)
+		if err != nil {
+			return call, err
+		}
+		call.args = args
+		p.skipBlanks()
+		if p.pos < len(p.src) && p.peek() != ':' {
+			return call, p.errorf(p.pos, "unexpected %q after %s(...)", string(p.peek()), call.name)
+		}
+	}
+	call.text = strings.TrimSpace(p.src[start:p.pos])
+	return call, nil
+}
+
+// args parses the argument list after "(" up to and including the closing ")".
+func (p *placeholderParser) args(open int) ([]transformArg, error) {
+	if p.peek() == ')' {
+		p.pos++
+		return nil, nil
+	}
+	var args []transformArg
+	for {
+		arg, err := p.arg(open)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return args, nil
+		default:
+			return nil, p.errorf(open, "unclosed '(' in transformation")
+		}
+	}
+}
+
+func (p *placeholderParser) arg(open int) (transformArg, error) {
+	start := p.pos
+	p.skipBlanks()
+	if c := p.peek(); c == '"' || c == '\'' {
+		value, err := p.quoted()
+		if err != nil {
+			return transformArg{}, err
+		}
+		p.skipBlanks()
+		if c := p.peek(); c != ',' && c != ')' && p.pos < len(p.src) {
+			return transformArg{}, p.errorf(p.pos, "unexpected %q after quoted argument", string(c))
+		}
+		return transformArg{value: value, quoted: true}, nil
+	}
+
+	p.pos = start
+	depth := 0
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos++
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return transformArg{value: rawArg(p.src[start:p.pos])}, nil
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				return transformArg{value: rawArg(p.src[start:p.pos])}, nil
+			}
+		}
+		p.pos++
+	}
+	return transformArg{}, p.errorf(open, "unclosed '(' in transformation")
+}
+
+// rawArg unescapes "\," in an unquoted argument; other backslashes are kept so
+// regular expressions such as "\d+" or "\)" work unquoted.
+func rawArg(s string) string {
+	return strings.ReplaceAll(s, `\,`, ",")
+}
+
+// quoted parses a quoted string starting at the opening quote.
+func (p *placeholderParser) quoted() (string, error) {
+	open := p.pos
+	q := p.src[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == q:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			next := p.src[p.pos+1]
+			switch next {
+			case '"', '\'', '\\':
+				sb.WriteByte(next)
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(c)
+				sb.WriteByte(next)
+			}
+			p.pos += 2
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf(open, "unterminated string")
+}
+
+// argValues returns the argument values of call, fitted to arity: when there are more
+// unquoted arguments than expected, the extra ones are joined back with commas into
+// the last argument, as earlier versions split only up to the arity.
+func (call transformCall) argValues(arity int) []string {
+	values := make([]string, len(call.args))
+	for i, a := range call.args {
+		values[i] = a.value
+	}
+	if arity > 0 && len(values) > arity {
+		for _, a := range call.args[arity-1:] {
+			if a.quoted {
+				return values
+			}
+		}
+		values = append(values[:arity-1], strings.Join(values[arity-1:], ","))
+	}
+	return values
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// applyChain parses "value:t1:t2..." like a placeholder and applies the transformations.
+func applyChain(fr *FileReplacer, value string, transforms ...string) (string, error) {
+	expr, err := parsePlaceholderExpr("V:" + strings.Join(transforms, ":"))
+	if err != nil {
+		return "", err
+	}
+	return fr.applyTransformations(value, expr.transforms)
+}
+
+func TestParsePlaceholderExpr(t *testing.T) {
+	tests := []struct {
+		src  string
+		key  placeholderKey
+		args [][]string
+	}{
+		{"NAME", placeholderKey{name: "NAME"}, nil},
+		{" NAME ", placeholderKey{name: " NAME "}, nil},
+		{"NAME!:toUpperCase", placeholderKey{name: "NAME", required: true}, [][]string{nil}},
+		{`PORT|"8080:80"`, placeholderKey{name: "PORT", def: "8080:80", hasDefault: true}, nil},
+		{"ENV|dev:toUpperCase", placeholderKey{name: "ENV", def: "dev", hasDefault: true}, [][]string{nil}},
+		{"V:gsub(a:b,c)", placeholderKey{name: "V"}, [][]string{{"a:b", "c"}}},
+		{`V:gsub(",", "\"-\"")`, placeholderKey{name: "V"}, [][]string{{",", `"-"`}}},
+		{`V:replaceRegex((\w+)-(\w+),$2)`, placeholderKey{name: "V"}, [][]string{{`(\w+)-(\w+)`, "$2"}}},
+		{`V:replaceRegex('\)$', ")")`, placeholderKey{name: "V"}, [][]string{{`\)$`, ")"}}},
+		{"V:gsub( ,-):trim", placeholderKey{name: "V"}, [][]string{{" ", "-"}, nil}},
+		{"V:trim()", placeholderKey{name: "V"}, [][]string{nil}},
+	}
+	for _, tt := range tests {
+		expr, err := parsePlaceholderExpr(tt.src)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.src, err)
+			continue
+		}
+		if expr.key != tt.key {
+			t.Errorf("%s: key = %+v, expected %+v", tt.src, expr.key, tt.key)
+		}
+		var args [][]string
+		for _, call := range expr.transforms {
+			var values []string
+			for _, a := range call.args {
+				values = append(values, a.value)
+			}
+			args = append(args, values)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: args = %q, expected %q", tt.src, args, tt.args)
+		}
+	}
+}
+
+func TestQuotedTransformationArguments(t *testing.T) {
+	fr := &FileReplacer{}
+	tests := []struct {
+		input     string
+		transform string
+		expected  string
+	}{
+		{"a,b,c", `gsub(",",-)`, "a-b-c"},
+		{"a-b", `gsub(-,", ")`, "a, b"},
+		{"10:30", `gsub(":",h)`, "10h30"},
+		{"a:b", "gsub(a:b,c)", "c"},
+		{"f(x)", `gsub("(x)",'(y)')`, "f(y)"},
+		{"it is", `gsub(" ",'\'')`, "it'is"},
+		{"a b", `gsub(" ","\t")`, "a\tb"},
+		{"v1.2.3-rc1", `replaceRegex("-rc\d+$","")`, "v1.2.3"},
+		{"John Smith", `replaceRegex((\w+) (\w+),$2 $1)`, "Smith John"},
+		{"a,b", `gsub(\,,;)`, "a;b"},
+		{"My Project", "gsub( ,-)", "My-Project"},
+		{"My Project", "gsub(,_)", "My_Project"},
+		{"a,b", "gsub(b,c,d)", "a,c,d"},
+		{"demo", "toUpperCase()", "DEMO"},
+	}
+	for _, tt := range tests {
+		got, err := applyChain(fr, tt.input, tt.transform)
+		if err != nil {
+			t.Fatalf("%s on %q failed: %v", tt.transform, tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s on %q = %q, expected %q", tt.transform, tt.input, got, tt.expected)
+		}
+	}
+
+	if _, err := applyChain(fr, "a b", `gsub("",-)`); err == nil || !strings.Contains(err.Error(), "old must not be empty") {
+		t.Errorf("expected an empty old argument error, got %v", err)
+	}
+	if _, err := applyChain(fr, "a", "toUpperCase(x)"); err == nil || !strings.Contains(err.Error(), "toUpperCase expects 0 argument(s), got 1") {
+		t.Errorf("expected an argument count error, got %v", err)
+	}
+}
+
+func TestPlaceholderSyntaxErrors(t *testing.T) {
+	scope := &renderScope{values: map[string]string{"NAME": "demo"}}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x\n  [[NAME:gsub(a,b]]", `main.go:2:14: invalid placeholder 'NAME:gsub(a,b': unclosed '(' in transformation`},
+		{`[[NAME:gsub("a,b)]]`, `main.go:1:13: invalid placeholder 'NAME:gsub("a,b)': unterminated string`},
+		{`[[NAME:gsub("a"x,b)]]`, `main.go:1:16: invalid placeholder 'NAME:gsub("a"x,b)': unexpected "x" after quoted argument`},
+		{"[[NAME:gsub(a,b)x]]", `main.go:1:17: invalid placeholder 'NAME:gsub(a,b)x': unexpected "x" after gsub(...)`},
+		{"[[NAME::trim]]", `main.go:1:8: invalid placeholder 'NAME::trim': missing transformation name`},
+		{"[[NAME|'x]]", `main.go:1:8: invalid placeholder 'NAME|'x': unterminated string`},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		_, _, err := fr.renderText("main.go", tt.input, scope, "[[", "]]")
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.input)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%s: error = %q, expected %q", tt.input, err.Error(), tt.expected)
+		}
+	}
+
+	// Malformed tags without a value are left alone, like any unknown placeholder
+	got, _, err := fr.renderText("run.sh", `[[ "$X" =~ a:(b ]] && [[NAME]]`, scope, "[[", "]]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != `[[ "$X" =~ a:(b ]] && demo` {
+		t.Errorf("got %q", got)
+	}
+}
@@ -3,8 +3,10 @@ package services
 import (
 	"bytes"
 	"fmt"
+	"os"
+	pathpkg "path"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -15,12 +17,58 @@ import (
 
 type Replacer interface {
 	ReplaceInDir(dir string, replacements domain.InputReplacement, fileSizeLimit string, startDelim string, endDelim string, verbose bool) error
-	AnalyzeDir(dir string, fileSizeLimit string, startDelim string, endDelim string, onlyTemplates bool) (map[string]int, error)
+	AnalyzeDir(dir string, ignorePatterns []string, fileSizeLimit string, startDelim string, endDelim string, onlyTemplates bool) (map[string]int, error)
+	// AnalyzePlaceholders is like AnalyzeDir but also reports inline defaults and required markers
+	AnalyzePlaceholders(dir string, ignorePatterns []string, fileSizeLimit string, startDelim string, endDelim string, onlyTemplates bool) (map[string]*PlaceholderInfo, error)
 	ProcessTemplateFiles(dir string, replacements domain.InputReplacement, fileSizeLimit string, startDelim string, endDelim string, verbose bool) error
+	// ResolveDerived renders the derived values (values referencing other variables) in dependency order
+	ResolveDerived(values map[string]string, derived map[string]string, startDelim string, endDelim string) (map[string]string, error)
+	// WithFileSystem returns a copy of the replacer that reads and writes through fs
+	WithFileSystem(fs FileSystem) Replacer
+	// WithDelimiters returns a copy of the replacer that uses per-file delimiters for the files the rules match
+	WithDelimiters(rules []domain.DelimiterRule) (Replacer, error)
+	// WithEngine returns a copy of the replacer that renders template files with the given engine
+	WithEngine(engine string) (Replacer, error)
+	// WithTransformers returns a copy of the replacer that also knows the given transformations
+	WithTransformers(ts ...Transformer) (Replacer, error)
+	// WithTemplateSuffixes returns a copy of the replacer that treats the files marked by ts as template files
+	WithTemplateSuffixes(ts TemplateSuffixes) (Replacer, error)
+	// RemovePartials deletes the partials directory once ReplaceInDir and ProcessTemplateFiles are done.
+	// It is meant for fresh clone or generate output, never for a tree templated in place.
+	RemovePartials(dir string, verbose bool) error
+	// UsesPartials reports whether a file below dir includes a partial, that is whether
+	// _partials is the partials source rather than an unrelated directory
+	UsesPartials(dir string, ignorePatterns []string, fileSizeLimit string, startDelim string, endDelim string) (bool, error)
+	// LoadManifest reads the yankrun.yaml manifest of dir, or returns nil when there is none
+	LoadManifest(dir string) (*domain.Manifest, error)
+	// RemoveManifest deletes the manifest once ReplaceInDir and ProcessTemplateFiles are done.
+	// Like RemovePartials, it is meant for fresh clone or generate output only.
+	RemoveManifest(dir string, verbose bool) error
 }
 
 type FileReplacer struct {
 	FileSystem FileSystem
+
+	delimiters []delimiterRule    // per-file delimiters, see WithDelimiters
+	engine     string             // engine for template files, see WithEngine; empty means yankrun
+	templates  TemplateSuffixes   // suffixes of template files, see WithTemplateSuffixes
+	transforms *TransformRegistry // transformations, see WithTransformers; nil means the built-ins
+}
+
+// PlaceholderInfo describes a variable discovered by AnalyzePlaceholders.
+type PlaceholderInfo struct {
+	Count      int
+	Default    string // inline default from [[KEY|default]], the first one found wins
+	HasDefault bool
+	Required   bool     // marked with [[KEY!]] at least once
+	RequiredAt []string // file:line of each required occurrence
+	Styles     []string // delimiter styles ("[[ ]]") the key was found in, in discovery order
+}
+
+func (fr *FileReplacer) WithFileSystem(fs FileSystem) Replacer {
+	clone := *fr
+	clone.FileSystem = fs
+	return &clone
 }
 
 func (fr *FileReplacer) ReplaceInDir(dir string, replacements domain.InputReplacement, fileSizeLimit string, startDelim string, endDelim string, verbose bool) error {
@@ -29,127 +77,176 @@ func (fr *FileReplacer) ReplaceInDir(dir string, replacements domain.InputReplac
 		return err
 	}
 
-	return fr.replacePatterns(dir, replacements, fileSizeInBytes, startDelim, endDelim, verbose)
+	ignore := NewIgnoreMatcher(replacements.IgnorePath)
+	excluded, err := fr.excludedPaths(dir, ignore, replacements)
+	if err != nil {
+		return err
+	}
+	// Renames are planned before anything is written, so a collision leaves the tree as it was
+	renames, err := fr.planRenames(dir, ignore, excluded, newRenderScope(replacements), startDelim, endDelim)
+	if err != nil {
+		return err
+	}
+	if err := fr.removePaths(dir, excluded, verbose); err != nil {
+		return err
+	}
+	if err := fr.replacePatterns(dir, ignore, replacements, fileSizeInBytes, startDelim, endDelim, verbose); err != nil {
+		return err
+	}
+	return fr.applyRenames(dir, renames, verbose)
 }
 
-// AnalyzeDir returns a map of placeholder -> count discovered in files within size limit
-func (fr *FileReplacer) AnalyzeDir(dir string, fileSizeLimit string, startDelim string, endDelim string, onlyTemplates bool) (map[string]int, error) {
+// AnalyzeDir returns a map of placeholder -> count discovered in files within size limit.
+// Paths matching ignorePatterns (gitignore syntax) are skipped.
+func (fr *FileReplacer) AnalyzeDir(dir string, ignorePatterns []string, fileSizeLimit string, startDelim string, endDelim string, onlyTemplates bool) (map[string]int, error) {
+	infos, err := fr.AnalyzePlaceholders(dir, ignorePatterns, fileSizeLimit, startDelim, endDelim, onlyTemplates)
 	result := map[string]int{}
+	for key, info := range infos {
+		result[key] = info.Count
+	}
+	return result, err
+}
+
+func (fr *FileReplacer) AnalyzePlaceholders(dir string, ignorePatterns []string, fileSizeLimit string, startDelim string, endDelim string, onlyTemplates bool) (map[string]*PlaceholderInfo, error) {
+	result := map[string]*PlaceholderInfo{}
 	fileSizeInBytes, err := fr.stringToBytes(fileSizeLimit)
 	if err != nil {
 		return result, err
 	}
-	err = fr.walkAndAnalyze(dir, fileSizeInBytes, startDelim, endDelim, result, onlyTemplates)
+	ignore := NewIgnoreMatcher(ignorePatterns)
+	err = fr.walkAndAnalyze(dir, ignore, fileSizeInBytes, startDelim, endDelim, newPartialSet(fr.FileSystem, dir), result, onlyTemplates)
 	return result, err
 }
 
-func (fr *FileReplacer) walkAndAnalyze(dir string, fileSizeInBytes int64, startDelim string, endDelim string, result map[string]int, onlyTemplates bool) error {
-	files, err := fr.FileSystem.ReadDir(dir)
-	if err != nil {
-		return err
-	}
-	for _, file := range files {
-		path := fr.FileSystem.Join(dir, file.Name())
-		info, err := fr.FileSystem.Stat(path)
-		if err != nil {
-			return err
-		}
+func (fr *FileReplacer) walkAndAnalyze(dir string, ignore *IgnoreMatcher, fileSizeInBytes int64, startDelim string, endDelim string, partials *partialSet, result map[string]*PlaceholderInfo, onlyTemplates bool) error {
+	return fr.walkEntries(dir, ignore, func(path, rel string, info os.FileInfo) error {
 		if info.IsDir() {
-			// Skip common directories
-			switch file.Name() {
-			case ".git", "node_modules", "vendor", "dist", "build", "bin":
-				continue
+			// Directory names are only templated by ReplaceInDir
+			if onlyTemplates {
+				return nil
 			}
-			if err := fr.walkAndAnalyze(path, fileSizeInBytes, startDelim, endDelim, result, onlyTemplates); err != nil {
-				return err
-			}
-			continue
+			return fr.countTemplateRefs(path, info.Name(), startDelim, endDelim, nil, result)
 		}
-
-		// Skip non-.tpl files when onlyTemplates is true
-		if onlyTemplates && !strings.HasSuffix(file.Name(), ".tpl") {
-			continue
+		// Skip non-template files when onlyTemplates is true
+		_, isTemplate := fr.templates.Trim(info.Name())
+		if onlyTemplates && !isTemplate {
+			return nil
+		}
+		if err := fr.countTemplateRefs(path, info.Name(), startDelim, endDelim, nil, result); err != nil {
+			return err
 		}
 		if !fr.checkFileSize(info, fileSizeInBytes, false) {
-			continue
+			return nil
 		}
 		content, err := fr.FileSystem.ReadFile(path)
 		if err != nil {
 			return err
 		}
 		if isBinary(content) || isBinaryByExt(path) {
-			continue
+			return nil
 		}
-		text := string(content)
-		// simple scan for startDelim ... endDelim occurrences
-		for {
-			start := strings.Index(text, startDelim)
-			if start == -1 {
-				break
-			}
-			text = text[start+len(startDelim):]
-			end := strings.Index(text, endDelim)
-			if end == -1 {
-				break
-			}
-			keyWithTransforms := text[:end]
-			baseKey, _, err := fr.parsePlaceholder(keyWithTransforms)
-			if err != nil {
-				// Log error but continue processing other placeholders
-				fmt.Printf("Error parsing placeholder '%s': %v\n", keyWithTransforms, err)
-				text = text[end+len(endDelim):]
-				continue
-			}
-			result[baseKey] = result[baseKey] + 1
-			text = text[end+len(endDelim):]
+		s, e := fr.delimitersFor(rel, startDelim, endDelim)
+		if !isTemplate {
+			return fr.countTemplateRefs(path, string(content), s, e, partials, result)
 		}
-	}
-	return nil
+		fm, body, err := splitFrontMatter(path, string(content))
+		if err != nil {
+			return err
+		}
+		if err := fr.frontMatterRefs(path, fm, s, e, result); err != nil {
+			return err
+		}
+		if fr.engine == EngineGoTemplate {
+			err = fr.countGoTemplateRefs(path, body, s, e, result)
+		} else {
+			err = fr.countTemplateRefs(path, body, s, e, partials, result)
+		}
+		return fm.shiftTemplateError(path, err)
+	})
 }
 
-// ProcessTemplateFiles processes .tpl files by evaluating templates and removing .tpl suffix
-func (fr *FileReplacer) ProcessTemplateFiles(dir string, replacements domain.InputReplacement, fileSizeLimit string, startDelim string, endDelim string, verbose bool) error {
-	fileSizeInBytes, err := fr.stringToBytes(fileSizeLimit)
-	if err != nil {
-		return err
-	}
+// walk visits every regular file below root that is not ignored, calling fn with
+// its path, its slash-separated path relative to root and its file info.
+// Ignored directories are not descended into.
+func (fr *FileReplacer) walk(root string, ignore *IgnoreMatcher, fn func(path, rel string, info os.FileInfo) error) error {
+	return fr.walkEntries(root, ignore, func(path, rel string, info os.FileInfo) error {
+		if info.IsDir() {
+			return nil
+		}
+		return fn(path, rel, info)
+	})
+}
 
-	return fr.processTemplateFilesRecursive(dir, replacements, fileSizeInBytes, startDelim, endDelim, verbose)
+// walkEntries is like walk but also visits directories, before their contents.
+func (fr *FileReplacer) walkEntries(root string, ignore *IgnoreMatcher, fn func(path, rel string, info os.FileInfo) error) error {
+	return fr.walkDir(root, "", ignore, fn)
 }
 
-func (fr *FileReplacer) processTemplateFilesRecursive(dir string, replacements domain.InputReplacement, fileSizeInBytes int64, startDelim string, endDelim string, verbose bool) error {
+func (fr *FileReplacer) walkDir(dir, relDir string, ignore *IgnoreMatcher, fn func(path, rel string, info os.FileInfo) error) error {
 	files, err := fr.FileSystem.ReadDir(dir)
 	if err != nil {
 		return err
 	}
-
 	for _, file := range files {
 		path := fr.FileSystem.Join(dir, file.Name())
+		rel := file.Name()
+		if relDir != "" {
+			rel = relDir + "/" + file.Name()
+		}
 		info, err := fr.FileSystem.Stat(path)
 		if err != nil {
 			return err
 		}
-
 		if info.IsDir() {
-			// Skip common directories
-			switch file.Name() {
-			case ".git", "node_modules", "vendor", "dist", "build", "bin":
+			if ignore.Match(rel, true) {
 				continue
 			}
-			err := fr.processTemplateFilesRecursive(path, replacements, fileSizeInBytes, startDelim, endDelim, verbose)
-			if err != nil {
+			if err := fn(path, rel, info); err != nil {
+				return err
+			}
+			if err := fr.walkDir(path, rel, ignore, fn); err != nil {
 				return err
 			}
 			continue
 		}
-
-		// Only process .tpl files
-		if !strings.HasSuffix(file.Name(), ".tpl") {
+		if ignore.Match(rel, false) {
 			continue
 		}
+		if err := fn(path, rel, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProcessTemplateFiles processes template files (.tpl by default, see WithTemplateSuffixes)
+// by evaluating templates and removing their suffix
+func (fr *FileReplacer) ProcessTemplateFiles(dir string, replacements domain.InputReplacement, fileSizeLimit string, startDelim string, endDelim string, verbose bool) error {
+	fileSizeInBytes, err := fr.stringToBytes(fileSizeLimit)
+	if err != nil {
+		return err
+	}
+
+	ignore := NewIgnoreMatcher(replacements.IgnorePath)
+	if err := fr.removeExcludedPaths(dir, ignore, replacements, verbose); err != nil {
+		return err
+	}
+	return fr.processTemplateFilesRecursive(dir, ignore, replacements, fileSizeInBytes, startDelim, endDelim, verbose)
+}
+
+func (fr *FileReplacer) processTemplateFilesRecursive(dir string, ignore *IgnoreMatcher, replacements domain.InputReplacement, fileSizeInBytes int64, startDelim string, endDelim string, verbose bool) error {
+	values := newRenderScope(replacements)
+	values.partials = newPartialSet(fr.FileSystem, dir)
+	data := goTemplateData(replacements)
+	return fr.walk(dir, ignore, func(path, rel string, info os.FileInfo) error {
+		// Only process template files
+		name, isTemplate := fr.templates.Trim(info.Name())
+		if !isTemplate {
+			return nil
+		}
 
 		if !fr.checkFileSize(info, fileSizeInBytes, verbose) {
-			continue
+			return nil
 		}
 
 		content, err := fr.FileSystem.ReadFile(path)
@@ -157,129 +254,168 @@ func (fr *FileReplacer) processTemplateFilesRecursive(dir string, replacements d
 			return err
 		}
 		if isBinary(content) {
-			continue
+			return nil
 		}
 
-		// Process the template content
-		newContent := string(content)
-		numReplacements := 0
-		
-		// Create a map for quick lookup of replacement values by base key
-		replacementValues := make(map[string]string)
-		for _, r := range replacements.Variables {
-			replacementValues[r.Key] = r.Value
+		// Process the template content, without its front matter
+		s, e := fr.delimitersFor(rel, startDelim, endDelim)
+		fm, body, err := splitFrontMatter(path, string(content))
+		if err != nil {
+			return err
 		}
-
-		// Find all placeholders in the content
-		placeholderRegex := regexp.MustCompile(regexp.QuoteMeta(startDelim) + `(.*?)` + regexp.QuoteMeta(endDelim))
-
-		// Find all matches
-		matches := placeholderRegex.FindAllStringSubmatchIndex(newContent, -1)
-
-		// Process matches in reverse order to avoid issues with index changes
-		for i := len(matches) - 1; i >= 0; i-- {
-			match := matches[i]
-			fullMatchStart, fullMatchEnd := match[0], match[1]
-			placeholderContentStart, placeholderContentEnd := match[2], match[3]
-
-			placeholderWithTransforms := newContent[placeholderContentStart:placeholderContentEnd]
-
-			baseKey, transformations, err := fr.parsePlaceholder(placeholderWithTransforms)
+		if fm.when != "" {
+			ok, err := EvalCondition(fm.when, values.lookup)
 			if err != nil {
-				if verbose {
-					fmt.Printf("Error parsing placeholder '%s': %v\n", placeholderWithTransforms, err)
-				}
-				continue
+				return fmt.Errorf("%s: front matter: when %q: %w", path, fm.when, err)
 			}
-
-			// Get the base value
-			baseValue, ok := replacementValues[baseKey]
 			if !ok {
-				// If no replacement value is found, skip this placeholder
-				continue
-			}
-
-			// Apply transformations
-			finalValue, err := fr.applyTransformations(baseValue, transformations)
-			if err != nil {
 				if verbose {
-					fmt.Printf("Error applying transformations for '%s': %v\n", placeholderWithTransforms, err)
+					fmt.Printf("Removed %s (%s is false)\n", path, fm.when)
 				}
-				continue
+				return fr.FileSystem.Remove(path)
 			}
-
-			// Replace the full placeholder (including delimiters) with the final value
-			newContent = newContent[:fullMatchStart] + finalValue + newContent[fullMatchEnd:]
-			numReplacements++
+		}
+		var newContent string
+		var numReplacements int
+		if fr.engine == EngineGoTemplate {
+			newContent, numReplacements, err = fr.renderGoTemplate(path, body, data, s, e)
+		} else {
+			newContent, numReplacements, err = fr.renderText(path, body, values, s, e)
+		}
+		if err != nil {
+			return fm.shiftTemplateError(path, err)
 		}
 
-		// Create new filename without .tpl suffix
-		newPath := strings.TrimSuffix(path, ".tpl")
-		
-		// Write the processed content to the new file
-		err = fr.FileSystem.WriteFile(newPath, []byte(newContent), 0644)
+		// Create new filename without the template suffix, templating the name itself,
+		// unless the front matter sets the destination
+		var newPath string
+		if fm.dest != "" {
+			newPath, err = fr.templateDest(dir, path, fm, values, s, e)
+		} else {
+			var newName string
+			newName, _, err = fr.renderText(path, name, values, startDelim, endDelim)
+			newPath = fr.FileSystem.Join(filepath.Dir(path), newName)
+		}
 		if err != nil {
 			return err
 		}
-
-		// Remove the original .tpl file
-		err = fr.FileSystem.Remove(path)
+		keep, err := fr.keepExisting(newPath, fm, body, newContent)
 		if err != nil {
 			return err
 		}
+		if keep {
+			if verbose {
+				fmt.Printf("Kept existing %s (overwrite: %s)\n", newPath, fm.overwrite)
+			}
+			return fr.FileSystem.Remove(path)
+		}
+		if err := fr.FileSystem.EnsureDir(filepath.Dir(newPath)); err != nil {
+			return err
+		}
 
-		if verbose && numReplacements != 0 {
-			fmt.Printf("Processed template %s -> %s (%d replacements)\n", file.Name(), fr.FileSystem.Base(newPath), numReplacements)
+		if fm.hasMode {
+			// A fresh file, so the mode applies even when the destination exists
+			if err := fr.FileSystem.Remove(path); err != nil {
+				return err
+			}
+			if _, err := fr.FileSystem.Stat(newPath); err == nil {
+				if err := fr.FileSystem.Remove(newPath); err != nil {
+					return err
+				}
+			}
+			if err := fr.FileSystem.WriteFile(newPath, []byte(newContent), fm.mode); err != nil {
+				return err
+			}
+			// WriteFile is subject to the umask, the declared mode is not
+			if err := fr.FileSystem.Chmod(newPath, fm.mode); err != nil {
+				return err
+			}
+		} else {
+			// Rewrite the template in place when its content changed, then move it to its
+			// final name, so the result keeps the template's permission bits and owner
+			if newContent != string(content) {
+				if err := fr.FileSystem.WriteFile(path, []byte(newContent), info.Mode().Perm()); err != nil {
+					return err
+				}
+			}
+			if err := fr.FileSystem.Rename(path, newPath); err != nil {
+				return err
+			}
 		}
-	}
 
-	return nil
+		if verbose && numReplacements != 0 {
+			fmt.Printf("Processed template %s -> %s (%d replacements)\n", info.Name(), fr.FileSystem.Base(newPath), numReplacements)
+		}
+		return nil
+	})
 }
 
-// parsePlaceholder extracts the base key and transformation functions from a placeholder string.
-// Example: "WORLD:gsub(WORLD,galaxy):toUpperCase" -> "WORLD", ["gsub(WORLD,galaxy)", "toUpperCase"]
-func (fr *FileReplacer) parsePlaceholder(placeholder string) (string, []string, error) {
-	parts := strings.Split(placeholder, ":")
-	if len(parts) == 0 {
-		return "", nil, fmt.Errorf("empty placeholder")
-	}
-
-	baseKey := parts[0]
-	var transformations []string
-	if len(parts) > 1 {
-		transformations = parts[1:]
+func addPlaceholderRef(result map[string]*PlaceholderInfo, key string) *PlaceholderInfo {
+	info, ok := result[key]
+	if !ok {
+		info = &PlaceholderInfo{}
+		result[key] = info
 	}
-	return baseKey, transformations, nil
+	info.Count++
+	return info
 }
 
-func (fr *FileReplacer) replacePatterns(dir string, replacements domain.InputReplacement, fileSizeInBytes int64, startDelim string, endDelim string, verbose bool) error {
-	files, err := fr.FileSystem.ReadDir(dir)
+// countTemplateRefs adds every variable referenced by placeholders and block conditions in text,
+// and in the partials it includes when partials is set, to result
+func (fr *FileReplacer) countTemplateRefs(file, text string, startDelim string, endDelim string, partials *partialSet, result map[string]*PlaceholderInfo) error {
+	if !strings.Contains(text, startDelim) {
+		return nil
+	}
+	nodes, err := parseTemplate(file, text, startDelim, endDelim)
 	if err != nil {
 		return err
 	}
-
-	for _, file := range files {
-		path := fr.FileSystem.Join(dir, file.Name())
-		info, err := fr.FileSystem.Stat(path)
-		if err != nil {
+	if partials != nil {
+		if err := partials.expand(file, nodes, startDelim, endDelim, nil); err != nil {
 			return err
 		}
+	}
+	found := map[string]*PlaceholderInfo{}
+	fr.collectTemplateRefs(file, nodes, nil, found)
+	mergePlaceholderInfo(result, found, DelimiterStyle(startDelim, endDelim))
+	return nil
+}
 
-		if info.IsDir() {
-			// Skip common directories
-			switch file.Name() {
-			case ".git", "node_modules", "vendor", "dist", "build", "bin":
-				continue
-			}
-			err := fr.replacePatterns(path, replacements, fileSizeInBytes, startDelim, endDelim, verbose)
-			if err != nil {
-				return err
-			}
-			continue
+// mergePlaceholderInfo adds the placeholders found in one text, written in style, to result.
+func mergePlaceholderInfo(result, found map[string]*PlaceholderInfo, style string) {
+	for key, f := range found {
+		info, ok := result[key]
+		if !ok {
+			info = &PlaceholderInfo{}
+			result[key] = info
+		}
+		info.Count += f.Count
+		if f.HasDefault && !info.HasDefault {
+			info.Default, info.HasDefault = f.Default, true
+		}
+		info.Required = info.Required || f.Required
+		info.RequiredAt = append(info.RequiredAt, f.RequiredAt...)
+		if !containsString(info.Styles, style) {
+			info.Styles = append(info.Styles, style)
 		}
+	}
+}
 
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (fr *FileReplacer) replacePatterns(dir string, ignore *IgnoreMatcher, replacements domain.InputReplacement, fileSizeInBytes int64, startDelim string, endDelim string, verbose bool) error {
+	values := newRenderScope(replacements)
+	values.partials = newPartialSet(fr.FileSystem, dir)
+	return fr.walk(dir, ignore, func(path, rel string, info os.FileInfo) error {
 		if !fr.checkFileSize(info, fileSizeInBytes, verbose) {
-			continue
+			return nil
 		}
 
 		content, err := fr.FileSystem.ReadFile(path)
@@ -287,132 +423,131 @@ func (fr *FileReplacer) replacePatterns(dir string, replacements domain.InputRep
 			return err
 		}
 		if isBinary(content) || isBinaryByExt(path) {
-			continue
+			return nil
 		}
 
-		newContent := string(content)
-		numReplacements := 0
-		// Create a map for quick lookup of replacement values by base key
-		replacementValues := make(map[string]string)
-		for _, r := range replacements.Variables {
-			replacementValues[r.Key] = r.Value
+		s, e := fr.delimitersFor(rel, startDelim, endDelim)
+		newContent, numReplacements, err := fr.renderText(path, string(content), values, s, e)
+		if err != nil {
+			return err
 		}
 
-		// Find all placeholders in the content
-		// This regex finds content between startDelim and endDelim
-		// It's a simple non-greedy match, adjust if delimiters can be nested or contain special regex chars
-		placeholderRegex := regexp.MustCompile(regexp.QuoteMeta(startDelim) + `(.*?)` + regexp.QuoteMeta(endDelim))
-
-		// Find all matches
-		matches := placeholderRegex.FindAllStringSubmatchIndex(newContent, -1)
+		// Leave untouched files alone so mtimes (and anything watching them) are not disturbed
+		if newContent == string(content) {
+			return nil
+		}
+		if err := fr.FileSystem.WriteFile(path, []byte(newContent), info.Mode().Perm()); err != nil {
+			return err
+		}
 
-		// Process matches in reverse order to avoid issues with index changes
-		for i := len(matches) - 1; i >= 0; i-- {
-			match := matches[i]
-			fullMatchStart, fullMatchEnd := match[0], match[1]
-			placeholderContentStart, placeholderContentEnd := match[2], match[3]
+		if verbose && numReplacements != 0 {
+			fmt.Printf("Replaced %d instances in %s\n", numReplacements, info.Name())
+		}
+		return nil
+	})
+}
 
-			placeholderWithTransforms := newContent[placeholderContentStart:placeholderContentEnd]
+// pathRename renames the entry at rel, keeping its parent, to newName.
+type pathRename struct {
+	rel     string
+	newName string
+	depth   int
+}
 
-			baseKey, transformations, err := fr.parsePlaceholder(placeholderWithTransforms)
+// planRenames computes the new name of every file and directory whose name contains
+// placeholders, skipping the excluded paths that are about to be removed. Collisions
+// (two paths ending up at the same destination, or a destination that already exists)
+// are reported before anything is moved or written.
+func (fr *FileReplacer) planRenames(dir string, ignore *IgnoreMatcher, excluded []excludedPath, values *renderScope, startDelim string, endDelim string) ([]pathRename, error) {
+	var renames []pathRename
+	targets := map[string]string{}
+	walked := map[string]bool{}
+	var collisions []string
+
+	err := fr.walkEntries(dir, ignore, func(path, rel string, info os.FileInfo) error {
+		walked[rel] = true
+		if isExcluded(excluded, path) {
+			return nil
+		}
+		segments := strings.Split(rel, "/")
+		for i, seg := range segments {
+			newSeg, _, err := fr.renderText(path, seg, values, startDelim, endDelim)
 			if err != nil {
-				fmt.Printf("Error parsing placeholder '%s': %v\n", placeholderWithTransforms, err)
-				continue
+				return err
 			}
-
-			// Get the base value
-			baseValue, ok := replacementValues[baseKey]
-			if !ok {
-				// If no replacement value is found, skip this placeholder
-				continue
+			segments[i] = newSeg
+		}
+		newRel := strings.Join(segments, "/")
+		for _, seg := range strings.Split(newRel, "/") {
+			if seg == "" || seg == "." || seg == ".." {
+				return fmt.Errorf("invalid path after templating %s: %s", rel, newRel)
 			}
+		}
+		if other, ok := targets[newRel]; ok {
+			collisions = append(collisions, fmt.Sprintf("%s and %s both map to %s", other, rel, newRel))
+		}
+		targets[newRel] = rel
 
-			// Apply transformations
-			finalValue, err := fr.applyTransformations(baseValue, transformations)
-			if err != nil {
-				fmt.Printf("Error applying transformations for '%s': %v\n", placeholderWithTransforms, err)
-				continue
-			}
+		newName := segments[len(segments)-1]
+		if newName != info.Name() {
+			renames = append(renames, pathRename{rel: rel, newName: newName, depth: len(segments)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			// Replace the full placeholder (including delimiters) with the final value
-			newContent = newContent[:fullMatchStart] + finalValue + newContent[fullMatchEnd:]
-			numReplacements++
+	// walked destinations are covered by targets; the others are ignored paths
+	for _, r := range renames {
+		destRel := pathpkg.Join(pathpkg.Dir(r.rel), r.newName)
+		if walked[destRel] {
+			continue
+		}
+		newPath := fr.FileSystem.Join(dir, filepath.FromSlash(destRel))
+		if _, err := fr.FileSystem.Stat(newPath); err == nil {
+			collisions = append(collisions, fmt.Sprintf("%s already exists", newPath))
 		}
+	}
+	if len(collisions) > 0 {
+		return nil, fmt.Errorf("path rename collision: %s", strings.Join(collisions, "; "))
+	}
+	return renames, nil
+}
 
-		err = fr.FileSystem.WriteFile(path, []byte(newContent), 0644)
-		if err != nil {
+// applyRenames runs the renames planned by planRenames, deepest-first so parent paths
+// stay valid while children move.
+func (fr *FileReplacer) applyRenames(dir string, renames []pathRename, verbose bool) error {
+	sort.SliceStable(renames, func(i, j int) bool { return renames[i].depth > renames[j].depth })
+	for _, r := range renames {
+		oldPath := fr.FileSystem.Join(dir, filepath.FromSlash(r.rel))
+		newPath := fr.FileSystem.Join(filepath.Dir(oldPath), filepath.FromSlash(r.newName))
+		if _, err := fr.FileSystem.Stat(newPath); err == nil {
+			return fmt.Errorf("path rename collision: %s already exists", newPath)
+		}
+		if err := fr.FileSystem.EnsureDir(filepath.Dir(newPath)); err != nil {
 			return err
 		}
-
-		if verbose && numReplacements != 0 {
-			fmt.Printf("Replaced %d instances in %s\n", numReplacements, file.Name())
+		if err := fr.FileSystem.Rename(oldPath, newPath); err != nil {
+			return err
+		}
+		if verbose {
+			fmt.Printf("Renamed %s -> %s\n", r.rel, pathpkg.Join(pathpkg.Dir(r.rel), r.newName))
 		}
 	}
-
 	return nil
 }
 
-// applyTransformations applies a series of transformation functions to a given value.
-func (fr *FileReplacer) applyTransformations(value string, transformations []string) (string, error) {
-	transformedValue := value
+// applyTransformations applies a chain of parsed transformations to a given value.
+func (fr *FileReplacer) applyTransformations(value string, transformations []transformCall) (string, error) {
+	registry := fr.transformRegistry()
 	for _, t := range transformations {
 		var err error
-		switch {
-		case strings.HasPrefix(t, "toUpperCase"):
-			transformedValue = strings.ToUpper(transformedValue)
-		case strings.HasPrefix(t, "toLowerCase"), strings.HasPrefix(t, "toDownCase"):
-			transformedValue = strings.ToLower(transformedValue)
-		case strings.HasPrefix(t, "gsub("):
-			transformedValue, err = fr.applyGsub(transformedValue, t)
-			if err != nil {
-				return "", err
-			}
-		default:
-			return "", fmt.Errorf("unsupported transformation function: %s", t)
+		if value, err = registry.apply(value, t); err != nil {
+			return "", err
 		}
 	}
-	return transformedValue, nil
-}
-
-// applyGsub applies the gsub transformation.
-// It parses arguments like "gsub(old,new)" or "gsub( ,new)"
-func (fr *FileReplacer) applyGsub(value, transformFunc string) (string, error) {
-	// Extract arguments from gsub(arg1,arg2)
-	argsStr := transformFunc[len("gsub("):strings.LastIndex(transformFunc, ")")]
-
-	// Split arguments, handling escaped commas or commas within quotes if necessary
-	// For now, a simple split by comma, assuming no escaped commas or quotes
-	parts := fr.splitGsubArgs(argsStr)
-
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid gsub arguments: %s. Expected gsub(old,new)", transformFunc)
-	}
-
-	old := parts[0]
-	new := parts[1]
-
-	// Handle empty string for 'old' argument
-	if old == "" {
-		// Replace all spaces with 'new'
-		return strings.ReplaceAll(value, " ", new), nil
-	}
-
-	return strings.ReplaceAll(value, old, new), nil
-}
-
-// splitGsubArgs splits the arguments for gsub, handling potential empty strings for the 'old' argument.
-func (fr *FileReplacer) splitGsubArgs(argsStr string) []string {
-	// Find the first comma that is not inside a quoted string (if we were to support quotes)
-	// For now, we'll assume no quotes and just split by the first comma.
-	commaIndex := strings.Index(argsStr, ",")
-	if commaIndex == -1 {
-		return []string{argsStr} // Not enough arguments or malformed
-	}
-
-	arg1 := argsStr[:commaIndex]
-	arg2 := argsStr[commaIndex+1:]
-
-	return []string{arg1, arg2}
+	return value, nil
 }
 
 func isBinaryByExt(path string) bool {
@@ -3,7 +3,10 @@ package services
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/brasa-ai/yankrun/domain"
 )
@@ -406,3 +409,178 @@ This is a template file with TestProject.`
 		t.Errorf("Processed content mismatch. Expected: %s, Got: %s", expectedContent, string(processedContent))
 	}
 }
+
+func TestReplaceInDirRenamesPaths(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"cmd/[[APP_NAME]]/main.go":             "package main // [[APP_NAME]]",
+		"src/com/[[ORG:toLowerCase]]/App.java": "package com.[[ORG:toLowerCase]];",
+		"[[APP_NAME:toUpperCase]].md":          "# [[APP_NAME]]",
+		"docs/[[DOC_PATH]]/index.md":           "docs",
+		"untouched/readme.txt":                 "nothing here",
+		"[[MISSING]]/kept.txt":                 "no value for this one",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+
+	counts, err := replacer.AnalyzeDir(tempDir, nil, "3 mb", "[[", "]]", false)
+	if err != nil {
+		t.Fatalf("AnalyzeDir failed: %v", err)
+	}
+	// two path segments plus two occurrences in contents
+	if counts["APP_NAME"] != 4 {
+		t.Errorf("expected 4 matches for APP_NAME, got %d", counts["APP_NAME"])
+	}
+	if counts["DOC_PATH"] != 1 {
+		t.Errorf("expected 1 match for DOC_PATH, got %d", counts["DOC_PATH"])
+	}
+
+	replacements := domain.InputReplacement{
+		Variables: []domain.Replacement{
+			{Key: "APP_NAME", Value: "demo"},
+			{Key: "ORG", Value: "Acme"},
+			{Key: "DOC_PATH", Value: "io/acme"},
+		},
+	}
+	if err := replacer.ReplaceInDir(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"cmd/demo/main.go":      "package main // demo",
+		"src/com/acme/App.java": "package com.acme;",
+		"DEMO.md":               "# demo",
+		"docs/io/acme/index.md": "docs",
+		"untouched/readme.txt":  "nothing here",
+		"[[MISSING]]/kept.txt":  "no value for this one",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Errorf("expected %s to exist: %v", name, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, string(got))
+		}
+	}
+	for _, old := range []string{"cmd/[[APP_NAME]]", "src/com/[[ORG:toLowerCase]]", "[[APP_NAME:toUpperCase]].md"} {
+		if _, err := os.Stat(filepath.Join(tempDir, old)); err == nil {
+			t.Errorf("%s should have been renamed", old)
+		}
+	}
+}
+
+func TestReplaceInDirDetectsRenameCollisions(t *testing.T) {
+	tempDir := t.TempDir()
+	files := []string{"[[FIRST]].txt", "[[SECOND]].txt", "notes.txt", "helm/chart.yaml"}
+	if err := os.MkdirAll(filepath.Join(tempDir, "helm"), 0755); err != nil {
+		t.Fatalf("Failed to create test dir: %v", err)
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name+" [[FIRST]]\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	replacements := domain.InputReplacement{
+		Variables: []domain.Replacement{
+			{Key: "FIRST", Value: "same"},
+			{Key: "SECOND", Value: "same"},
+		},
+		PathConditions: []domain.PathCondition{{Path: "helm/", When: "K8S"}},
+	}
+	err := replacer.ReplaceInDir(tempDir, replacements, "3 mb", "[[", "]]", false)
+	if err == nil {
+		t.Fatal("expected a collision error")
+	}
+	if !strings.Contains(err.Error(), "same.txt") {
+		t.Errorf("expected collision error to name the destination, got: %v", err)
+	}
+	// nothing is renamed, removed or rewritten when a collision is detected
+	for _, name := range files {
+		got, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Errorf("%s should be left in place: %v", name, err)
+			continue
+		}
+		if string(got) != name+" [[FIRST]]\n" {
+			t.Errorf("%s should not have been rewritten, got %q", name, string(got))
+		}
+	}
+}
+
+func TestReplaceInDirPreservesModesAndSkipsUnchangedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	script := filepath.Join(tempDir, "gradlew")
+	untouched := filepath.Join(tempDir, "notes.txt")
+	tpl := filepath.Join(tempDir, "run.sh.tpl")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho [[APP_NAME]]\n"), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(untouched, []byte("no placeholders here\n"), 0640); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(tpl, []byte("#!/bin/sh\nexec [[APP_NAME]]\n"), 0750); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	// chmod explicitly so the umask does not interfere with the expected modes
+	for path, mode := range map[string]os.FileMode{script: 0755, untouched: 0640, tpl: 0750} {
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatalf("Failed to chmod %s: %v", path, err)
+		}
+	}
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(untouched, past, past); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+
+	replacer := &FileReplacer{FileSystem: &OsFileSystem{}}
+	replacements := domain.InputReplacement{
+		Variables: []domain.Replacement{{Key: "APP_NAME", Value: "demo"}},
+	}
+	if err := replacer.ReplaceInDir(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ReplaceInDir failed: %v", err)
+	}
+	if err := replacer.ProcessTemplateFiles(tempDir, replacements, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ProcessTemplateFiles failed: %v", err)
+	}
+
+	info, err := os.Stat(untouched)
+	if err != nil {
+		t.Fatalf("Failed to stat notes.txt: %v", err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Errorf("notes.txt should not have been rewritten, mtime changed to %v", info.ModTime())
+	}
+
+	for path, mode := range map[string]os.FileMode{script: 0755, untouched: 0640, filepath.Join(tempDir, "run.sh"): 0750} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", path, err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm() != mode {
+			t.Errorf("%s: expected mode %v, got %v", filepath.Base(path), mode, info.Mode().Perm())
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "run.sh"))
+	if err != nil {
+		t.Fatalf("Failed to read run.sh: %v", err)
+	}
+	if string(content) != "#!/bin/sh\nexec demo\n" {
+		t.Errorf("run.sh content mismatch, got %q", string(content))
+	}
+}
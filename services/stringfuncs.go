@@ -0,0 +1,153 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func stringParam(name string) TransformParam { return TransformParam{Name: name, Type: StringParam} }
+func countParam(name string) TransformParam  { return TransformParam{Name: name, Type: CountParam} }
+
+// stringTransformers are the string manipulation transformations.
+var stringTransformers = []Transformer{
+	NewTransformer(TransformSpec{Name: "gsub", Params: []TransformParam{stringParam("old"), stringParam("new")}, Category: "string",
+		Description: "Replaces every `old` with `new`; an unquoted empty `old` replaces spaces",
+		Example:     "[[VERSION:gsub(-,_)]]", Input: "1.0.0-alpha", Output: "1.0.0_alpha"}, gsub),
+	simpleTransformer(TransformSpec{Name: "trim", Category: "string", Description: "Removes leading and trailing whitespace",
+		Example: "[[NAME:trim]]", Input: "  app  ", Output: "app"}, strings.TrimSpace),
+	NewTransformer(TransformSpec{Name: "trimPrefix", Params: []TransformParam{stringParam("x")}, Category: "string",
+		Description: "Removes `x` from the start, if present",
+		Example:     "[[VERSION:trimPrefix(v)]]", Input: "v1.2.3", Output: "1.2.3"},
+		func(v string, a TransformArgs) (string, error) { return strings.TrimPrefix(v, a.String(0)), nil }),
+	NewTransformer(TransformSpec{Name: "trimSuffix", Params: []TransformParam{stringParam("x")}, Category: "string",
+		Description: "Removes `x` from the end, if present",
+		Example:     "[[FILE:trimSuffix(.go)]]", Input: "main.go", Output: "main"},
+		func(v string, a TransformArgs) (string, error) { return strings.TrimSuffix(v, a.String(0)), nil }),
+	NewTransformer(TransformSpec{Name: "substr", Params: []TransformParam{countParam("start"), countParam("length")}, Category: "string",
+		Description: "`length` characters starting at `start` (0-based)",
+		Example:     "[[SHA:substr(0,7)]]", Input: "3f2a9c1d4e", Output: "3f2a9c1"}, substr),
+	NewTransformer(TransformSpec{Name: "truncate", Params: []TransformParam{countParam("length")}, Category: "string",
+		Description: "At most the first `length` characters",
+		Example:     "[[TITLE:truncate(5)]]", Input: "Hello World", Output: "Hello"}, truncate),
+	NewTransformer(TransformSpec{Name: "padLeft", Params: []TransformParam{countParam("width"), stringParam("pad")}, Category: "string",
+		Description: "Pads on the left with the `pad` character up to `width` characters",
+		Example:     "[[BUILD:padLeft(4,0)]]", Input: "42", Output: "0042"}, padLeft),
+	NewTransformer(TransformSpec{Name: "repeat", Params: []TransformParam{countParam("count")}, Category: "string",
+		Description: "Repeats the value `count` times",
+		Example:     "[[SEP:repeat(3)]]", Input: "=", Output: "==="}, repeat),
+	NewTransformer(TransformSpec{Name: "replaceRegex", Params: []TransformParam{stringParam("pattern"), stringParam("repl")}, Category: "string",
+		Description: "Replaces every match of a [Go regular expression](https://pkg.go.dev/regexp/syntax); `repl` can use `$1`, `$2`, ...",
+		Example:     `[[VERSION:replaceRegex(-rc\d+$,)]]`, Input: "1.2.3-rc1", Output: "1.2.3"}, replaceRegex),
+	NewTransformer(TransformSpec{Name: "split", Params: []TransformParam{stringParam("sep"), {Name: "index", Type: IntParam}}, Category: "string",
+		Description: "Splits on `sep` and returns part `index`; negative indexes count from the end",
+		Example:     "[[REPO:split(/,-1)]]", Input: "github.com/acme/app", Output: "app"}, split),
+	simpleTransformer(TransformSpec{Name: "slugify", Category: "string", Description: "Lower-cases and joins runs of letters and digits with `-`",
+		Example: "[[TITLE:slugify]]", Input: "Hello, World!", Output: "hello-world"}, slugify),
+}
+
+// gsub replaces every old with new. An unquoted empty old argument, as in
+// "gsub(,new)", replaces spaces like "gsub( ,new)".
+func gsub(v string, a TransformArgs) (string, error) {
+	old, new := a.String(0), a.String(1)
+	if old == "" && !a.Quoted(0) {
+		return strings.ReplaceAll(v, " ", new), nil
+	}
+	if old == "" {
+		return "", fmt.Errorf("old must not be empty")
+	}
+	return strings.ReplaceAll(v, old, new), nil
+}
+
+// substr returns length runes starting at rune start, clamped to the value.
+func substr(v string, a TransformArgs) (string, error) {
+	start, length := a.Int(0), a.Int(1)
+	runes := []rune(v)
+	if start > len(runes) {
+		start = len(runes)
+	}
+	end := start + length
+	if end > len(runes) {
+		end = len(runes)
+	}
+	return string(runes[start:end]), nil
+}
+
+func truncate(v string, a TransformArgs) (string, error) {
+	n := a.Int(0)
+	runes := []rune(v)
+	if n < len(runes) {
+		runes = runes[:n]
+	}
+	return string(runes), nil
+}
+
+// maxGrownLength bounds the length of the values repeat and padLeft build, so a large
+// count fails with an argument error instead of exhausting memory.
+const maxGrownLength = 1 << 20
+
+func repeat(v string, a TransformArgs) (string, error) {
+	count := a.Int(0)
+	if count > 0 && len(v) > maxGrownLength/count {
+		return "", fmt.Errorf("count %d makes the value longer than %d bytes", count, maxGrownLength)
+	}
+	return strings.Repeat(v, count), nil
+}
+
+func padLeft(v string, a TransformArgs) (string, error) {
+	width, pad := a.Int(0), a.String(1)
+	if utf8.RuneCountInString(pad) != 1 {
+		return "", fmt.Errorf("pad must be a single character, got %q", pad)
+	}
+	if width > maxGrownLength {
+		return "", fmt.Errorf("width must be at most %d, got %d", maxGrownLength, width)
+	}
+	if missing := width - utf8.RuneCountInString(v); missing > 0 {
+		return strings.Repeat(pad, missing) + v, nil
+	}
+	return v, nil
+}
+
+func replaceRegex(v string, a TransformArgs) (string, error) {
+	re, err := regexp.Compile(a.String(0))
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %v", a.String(0), err)
+	}
+	return re.ReplaceAllString(v, a.String(1)), nil
+}
+
+// split returns the part at index idx after splitting on sep; negative indexes count from the end.
+func split(v string, a TransformArgs) (string, error) {
+	sep, idx := a.String(0), a.Int(1)
+	if sep == "" {
+		return "", fmt.Errorf("separator must not be empty")
+	}
+	parts := strings.Split(v, sep)
+	if idx < 0 {
+		idx += len(parts)
+	}
+	if idx < 0 || idx >= len(parts) {
+		return "", fmt.Errorf("index %d out of range for %d part(s)", a.Int(1), len(parts))
+	}
+	return parts[idx], nil
+}
+
+// slugify lower-cases v and joins its runs of letters and digits with "-".
+func slugify(v string) string {
+	var sb strings.Builder
+	pending := false
+	for _, r := range strings.ToLower(v) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pending && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			pending = false
+			sb.WriteRune(r)
+			continue
+		}
+		pending = true
+	}
+	return sb.String()
+}
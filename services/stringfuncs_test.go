@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringTransformations(t *testing.T) {
+	fr := &FileReplacer{}
+	tests := []struct {
+		input     string
+		transform string
+		expected  string
+	}{
+		{"  padded \t", "trim", "padded"},
+		{"  padded ", "trim()", "padded"},
+		{"v1.2.3", "trimPrefix(v)", "1.2.3"},
+		{"service.go", "trimSuffix(.go)", "service"},
+		{"héllo world", "substr(1,4)", "éllo"},
+		{"short", "substr(3,10)", "rt"},
+		{"short", "substr(10,2)", ""},
+		{"a very long name", "truncate(6)", "a very"},
+		{"tiny", "truncate(10)", "tiny"},
+		{"42", "padLeft(5,0)", "00042"},
+		{"abc", "padLeft(5, )", "  abc"},
+		{"toolong", "padLeft(3,0)", "toolong"},
+		{"ab", "repeat(3)", "ababab"},
+		{"v1.2.3-rc1", `replaceRegex(-rc\d+$,)`, "v1.2.3"},
+		{"John Smith", `replaceRegex((\w+) (\w+),$2 $1)`, "Smith John"},
+		{"a/b/c", "split(/,1)", "b"},
+		{"github.com/acme/app", "split(/,-1)", "app"},
+		{"  Hello, World! 2024 ", "slugify", "hello-world-2024"},
+		{"Ünïcode Straße", "slugify", "ünïcode-straße"},
+	}
+	for _, tt := range tests {
+		got, err := applyChain(fr, tt.input, tt.transform)
+		if err != nil {
+			t.Fatalf("%s on %q failed: %v", tt.transform, tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s on %q = %q, expected %q", tt.transform, tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestStringTransformationErrors(t *testing.T) {
+	scope := &renderScope{values: map[string]string{"NAME": "demo"}}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x\n[[NAME:substr(a,2)]]", "main.go:2:1: placeholder 'NAME:substr(a,2)': substr(a,2): start must be an integer"},
+		{"[[NAME:substr(1)]]", "substr expects 2 argument(s), got 1"},
+		{"[[NAME:truncate(-1)]]", "length must be at least 0"},
+		{"[[NAME:padLeft(5,ab)]]", "pad must be a single character"},
+		{"[[NAME:padLeft(9223372036854775807,0)]]", "width must be at most 1048576"},
+		{"[[NAME:repeat(9223372036854775807)]]", "count 9223372036854775807 makes the value longer than 1048576 bytes"},
+		{"[[NAME:repeat(300000)]]", "makes the value longer than"},
+		{"[[NAME:replaceRegex([,x)]]", "invalid pattern"},
+		{"[[NAME:split(/,3)]]", "index 3 out of range for 1 part(s)"},
+		{"[[NAME:trim(x)]]", "trim expects 0 argument(s), got 1"},
+		{"[[NAME:unknown]]", "unsupported transformation function: unknown"},
+	}
+
+	fr := &FileReplacer{}
+	for _, tt := range tests {
+		_, _, err := fr.renderText("main.go", tt.input, scope, "[[", "]]")
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.input)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: expected error containing %q, got %q", tt.input, tt.expected, err.Error())
+		}
+	}
+}
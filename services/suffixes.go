@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Template files are the files ProcessTemplateFiles renders and renames. They are
+// marked by a suffix, .tpl unless others are configured:
+//
+//	template_suffixes: [.tmpl, .j2]
+//	template_infix: true
+//
+// With infix the suffixes also mark templates when they come before the extension,
+// so config.tmpl.yaml is written to config.yaml.
+
+// DefaultTemplateSuffix marks template files when no suffixes are configured.
+const DefaultTemplateSuffix = ".tpl"
+
+// TemplateSuffixes selects the template files by name.
+type TemplateSuffixes struct {
+	Suffixes []string // such as .tmpl or .j2; DefaultTemplateSuffix when empty
+	Infix    bool     // also match a suffix before the extension (config.tpl.yaml)
+}
+
+// normalize adds the missing leading dots, drops duplicates and sorts the suffixes
+// longest first, so .html.j2 is preferred over .j2.
+func (ts TemplateSuffixes) normalize() (TemplateSuffixes, error) {
+	var suffixes []string
+	for _, s := range ts.Suffixes {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.HasPrefix(s, ".") {
+			s = "." + s
+		}
+		if s == "." || strings.ContainsAny(s, `/\`) {
+			return ts, fmt.Errorf("invalid template suffix %q", s)
+		}
+		if !containsString(suffixes, s) {
+			suffixes = append(suffixes, s)
+		}
+	}
+	if len(suffixes) == 0 {
+		suffixes = []string{DefaultTemplateSuffix}
+	}
+	sort.SliceStable(suffixes, func(i, j int) bool { return len(suffixes[i]) > len(suffixes[j]) })
+	return TemplateSuffixes{Suffixes: suffixes, Infix: ts.Infix}, nil
+}
+
+// WithTemplateSuffixes returns a copy of the replacer that treats the files marked by
+// ts as template files.
+func (fr *FileReplacer) WithTemplateSuffixes(ts TemplateSuffixes) (Replacer, error) {
+	ts, err := ts.normalize()
+	if err != nil {
+		return nil, err
+	}
+	clone := *fr
+	clone.templates = ts
+	return &clone, nil
+}
+
+// Trim returns name without its template suffix and whether name is a template file.
+func (ts TemplateSuffixes) Trim(name string) (string, bool) {
+	suffixes := ts.Suffixes
+	if len(suffixes) == 0 {
+		suffixes = []string{DefaultTemplateSuffix}
+	}
+	for _, s := range suffixes {
+		if strings.HasSuffix(name, s) && len(name) > len(s) {
+			return strings.TrimSuffix(name, s), true
+		}
+	}
+	if !ts.Infix {
+		return name, false
+	}
+	// the leftmost suffix followed by an extension: config.tpl.yaml
+	at, suffix := -1, ""
+	for _, s := range suffixes {
+		if i := strings.Index(name, s+"."); i > 0 && (at < 0 || i < at) {
+			at, suffix = i, s
+		}
+	}
+	if at < 0 {
+		return name, false
+	}
+	return name[:at] + name[at+len(suffix):], true
+}
+
+// trimPath is Trim for the last segment of a slash-separated path.
+func (ts TemplateSuffixes) trimPath(rel string) (string, bool) {
+	i := strings.LastIndex(rel, "/")
+	name, ok := ts.Trim(rel[i+1:])
+	return rel[:i+1] + name, ok
+}
+
+// IgnorePatterns returns gitignore patterns matching the template files, to leave them
+// out of a regular pass.
+func (ts TemplateSuffixes) IgnorePatterns() []string {
+	suffixes := ts.Suffixes
+	if len(suffixes) == 0 {
+		suffixes = []string{DefaultTemplateSuffix}
+	}
+	var patterns []string
+	for _, s := range suffixes {
+		patterns = append(patterns, "*"+s)
+		if ts.Infix {
+			patterns = append(patterns, "*"+s+".*")
+		}
+	}
+	return patterns
+}
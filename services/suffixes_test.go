@@ -0,0 +1,100 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brasa-ai/yankrun/domain"
+)
+
+func TestTemplateSuffixesTrim(t *testing.T) {
+	ts, err := TemplateSuffixes{Suffixes: []string{"j2", ".html.j2", ".tmpl", ".j2"}, Infix: true}.normalize()
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	if want := []string{".html.j2", ".tmpl", ".j2"}; !reflect.DeepEqual(ts.Suffixes, want) {
+		t.Errorf("expected %v, got %v", want, ts.Suffixes)
+	}
+
+	tests := []struct {
+		name       string
+		expected   string
+		isTemplate bool
+	}{
+		{"index.html.j2", "index", true},
+		{"main.go.tmpl", "main.go", true},
+		{"config.tmpl.yaml", "config.yaml", true},
+		{"a.j2.b.tmpl.c", "a.b.tmpl.c", true},
+		{"main.go.tpl", "main.go.tpl", false},
+		{".tmpl", ".tmpl", false},
+		{"notes.tmplx", "notes.tmplx", false},
+	}
+	for _, tt := range tests {
+		got, ok := ts.Trim(tt.name)
+		if got != tt.expected || ok != tt.isTemplate {
+			t.Errorf("%s: expected %q %v, got %q %v", tt.name, tt.expected, tt.isTemplate, got, ok)
+		}
+	}
+
+	// the default is .tpl at the end of names only
+	if got, ok := (TemplateSuffixes{}).Trim("config.tpl.yaml"); ok || got != "config.tpl.yaml" {
+		t.Errorf("expected no infix match by default, got %q %v", got, ok)
+	}
+	if got := (TemplateSuffixes{Infix: true}).IgnorePatterns(); !reflect.DeepEqual(got, []string{"*.tpl", "*.tpl.*"}) {
+		t.Errorf("unexpected ignore patterns %v", got)
+	}
+	if _, err := (TemplateSuffixes{Suffixes: []string{"a/b"}}).normalize(); err == nil || !strings.Contains(err.Error(), `invalid template suffix ".a/b"`) {
+		t.Errorf("expected an invalid suffix error, got %v", err)
+	}
+}
+
+func TestProcessTemplateSuffixes(t *testing.T) {
+	dir := writePartialsTree(t, map[string]string{
+		"README.md.tmpl":       "# [[APP_NAME]]\n",
+		"config.j2.yaml":       "name: [[APP_NAME]]\n",
+		"charts/values.j2":     "image: <<APP_NAME>>\n",
+		"docs/[[APP_NAME]].j2": "[[APP_NAME]]\n",
+		"legacy.txt.tpl":       "[[APP_NAME]]\n",
+	})
+
+	r, err := (&FileReplacer{FileSystem: &OsFileSystem{}}).WithTemplateSuffixes(TemplateSuffixes{Suffixes: []string{".tmpl", ".j2"}, Infix: true})
+	if err != nil {
+		t.Fatalf("WithTemplateSuffixes failed: %v", err)
+	}
+	if r, err = r.WithDelimiters([]domain.DelimiterRule{{Path: "charts/values", Start: "<<", End: ">>"}}); err != nil {
+		t.Fatalf("WithDelimiters failed: %v", err)
+	}
+	infos, err := r.AnalyzePlaceholders(dir, nil, "3 mb", "[[", "]]", true)
+	if err != nil {
+		t.Fatalf("AnalyzePlaceholders failed: %v", err)
+	}
+	// four templates plus the file name; legacy.txt.tpl is not a template
+	if a := infos["APP_NAME"]; a == nil || a.Count != 5 {
+		t.Errorf("unexpected APP_NAME info %+v", a)
+	}
+
+	repl := domain.InputReplacement{Variables: []domain.Replacement{{Key: "APP_NAME", Value: "demo"}}}
+	if err := r.ProcessTemplateFiles(dir, repl, "3 mb", "[[", "]]", false); err != nil {
+		t.Fatalf("ProcessTemplateFiles failed: %v", err)
+	}
+	expected := map[string]string{
+		"README.md":      "# demo\n",
+		"config.yaml":    "name: demo\n",
+		"charts/values":  "image: demo\n",
+		"docs/demo":      "demo\n",
+		"legacy.txt.tpl": "[[APP_NAME]]\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Transformations are looked up by name in a TransformRegistry. The built-in ones are
+// registered the same way as those added by programs that embed yankrun:
+//
+//	reverse := services.NewTransformer(services.TransformSpec{
+//		Name:        "reverse",
+//		Description: "Reverses the characters",
+//	}, func(v string, _ services.TransformArgs) (string, error) {
+//		r := []rune(v)
+//		slices.Reverse(r)
+//		return string(r), nil
+//	})
+//	replacer, err := replacer.WithTransformers(reverse)
+//
+// after which [[NAME:reverse]] works in templates, and [[ .NAME | reverse ]] with the
+// gotemplate engine.
+
+// ParamType is the type of a transformation argument, checked before Transform is called.
+type ParamType int
+
+const (
+	StringParam ParamType = iota // any text
+	IntParam                     // an integer
+	CountParam                   // an integer that is not negative
+)
+
+// TransformParam describes one argument of a transformation.
+type TransformParam struct {
+	Name string // used in error messages and in the reference
+	Type ParamType
+}
+
+// TransformSpec describes a transformation. Only Name is required; the rest documents it.
+type TransformSpec struct {
+	Name   string
+	Params []TransformParam // the last one takes the rest of an unquoted argument list
+
+	Category    string // reference table the transformation is listed in, see Reference
+	Description string
+	Example     string // placeholder using it; [[VALUE:name]] when empty
+	Input       string
+	Output      string
+}
+
+// Transformer is a named transformation applied with [[KEY:name(args)]].
+type Transformer interface {
+	Spec() TransformSpec
+	// Transform returns the transformed value. args match the Params of the spec in
+	// number and type.
+	Transform(value string, args TransformArgs) (string, error)
+}
+
+// TransformArgs are the arguments of a transformation call.
+type TransformArgs struct {
+	values []string
+	quoted []bool
+}
+
+// Len returns the number of arguments.
+func (a TransformArgs) Len() int { return len(a.values) }
+
+// String returns argument i as written.
+func (a TransformArgs) String(i int) string { return a.values[i] }
+
+// Int returns argument i of an IntParam or CountParam.
+func (a TransformArgs) Int(i int) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(a.values[i]))
+	return n
+}
+
+// Quoted reports whether argument i was quoted, so "" can be told apart from nothing.
+func (a TransformArgs) Quoted(i int) bool { return a.quoted[i] }
+
+type funcTransformer struct {
+	spec TransformSpec
+	fn   func(value string, args TransformArgs) (string, error)
+}
+
+func (t funcTransformer) Spec() TransformSpec { return t.spec }
+
+func (t funcTransformer) Transform(value string, args TransformArgs) (string, error) {
+	return t.fn(value, args)
+}
+
+// NewTransformer returns a Transformer described by spec that calls fn.
+func NewTransformer(spec TransformSpec, fn func(value string, args TransformArgs) (string, error)) Transformer {
+	return funcTransformer{spec: spec, fn: fn}
+}
+
+// simpleTransformer returns a Transformer without arguments that cannot fail.
+func simpleTransformer(spec TransformSpec, fn func(string) string) Transformer {
+	return NewTransformer(spec, func(v string, _ TransformArgs) (string, error) { return fn(v), nil })
+}
+
+// TransformRegistry holds transformations by name.
+type TransformRegistry struct {
+	byName map[string]Transformer
+	order  []string // registration order, used by Reference
+}
+
+// NewTransformRegistry returns an empty registry.
+func NewTransformRegistry() *TransformRegistry {
+	return &TransformRegistry{byName: map[string]Transformer{}}
+}
+
+// DefaultTransformRegistry returns a new registry holding the built-in transformations.
+func DefaultTransformRegistry() *TransformRegistry {
+	return builtinTransforms.Clone()
+}
+
+var builtinTransforms = newBuiltinTransforms()
+
+func newBuiltinTransforms() *TransformRegistry {
+	r := NewTransformRegistry()
+	for _, group := range [][]Transformer{caseTransformers, stringTransformers, encodingTransformers} {
+		for _, t := range group {
+			if err := r.Register(t); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return r
+}
+
+var transformNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Register adds t to the registry. Names must be identifiers and unique.
+func (r *TransformRegistry) Register(t Transformer) error {
+	name := t.Spec().Name
+	if !transformNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid transformation name %q", name)
+	}
+	if _, ok := r.byName[name]; ok {
+		return fmt.Errorf("transformation %q is already registered", name)
+	}
+	r.byName[name] = t
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Lookup returns the transformation called name.
+func (r *TransformRegistry) Lookup(name string) (Transformer, bool) {
+	t, ok := r.byName[name]
+	return t, ok
+}
+
+// Names returns the names of the registered transformations, sorted.
+func (r *TransformRegistry) Names() []string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Clone returns a copy of the registry that can be extended on its own.
+func (r *TransformRegistry) Clone() *TransformRegistry {
+	clone := NewTransformRegistry()
+	for name, t := range r.byName {
+		clone.byName[name] = t
+	}
+	clone.order = append(clone.order, r.order...)
+	return clone
+}
+
+// apply runs call on value, checking its arguments against the spec first.
+func (r *TransformRegistry) apply(value string, call transformCall) (string, error) {
+	t, ok := r.byName[call.name]
+	if !ok {
+		return "", fmt.Errorf("unsupported transformation function: %s", call.text)
+	}
+	params := t.Spec().Params
+	values := call.argValues(len(params))
+	if len(values) != len(params) {
+		return "", fmt.Errorf("%s expects %d argument(s), got %d", call.name, len(params), len(values))
+	}
+	args := TransformArgs{values: values, quoted: make([]bool, len(values))}
+	for i := range values {
+		// a joined argument list is never quoted as a whole
+		args.quoted[i] = len(call.args) == len(values) && call.args[i].quoted
+	}
+	for i, p := range params {
+		if err := checkParam(p, values[i]); err != nil {
+			return "", fmt.Errorf("%s: %w", call.text, err)
+		}
+	}
+	result, err := t.Transform(value, args)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", call.text, err)
+	}
+	return result, nil
+}
+
+func checkParam(p TransformParam, value string) error {
+	if p.Type == StringParam {
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("%s must be an integer, got %q", p.Name, value)
+	}
+	if p.Type == CountParam && n < 0 {
+		return fmt.Errorf("%s must be at least 0, got %d", p.Name, n)
+	}
+	return nil
+}
+
+// WithTransformers returns a copy of the replacer that also knows the transformations ts.
+func (fr *FileReplacer) WithTransformers(ts ...Transformer) (Replacer, error) {
+	registry := fr.transformRegistry().Clone()
+	for _, t := range ts {
+		if err := registry.Register(t); err != nil {
+			return nil, err
+		}
+	}
+	clone := *fr
+	clone.transforms = registry
+	return &clone, nil
+}
+
+// transformRegistry returns the transformations of the replacer, the built-ins unless
+// WithTransformers added others.
+func (fr *FileReplacer) transformRegistry() *TransformRegistry {
+	if fr.transforms == nil {
+		return builtinTransforms
+	}
+	return fr.transforms
+}
+
+var referencePattern = regexp.MustCompile(`(?s)(<!-- transformations:([\w-]+) -->\n).*?(<!-- /transformations -->)`)
+
+// Reference fills the reference tables of a Markdown document, such as doc/functions.md.
+// Each table sits between "<!-- transformations:CATEGORY -->" and
+// "<!-- /transformations -->" and lists the transformations of that category in the
+// order they were registered.
+func (r *TransformRegistry) Reference(doc string) string {
+	return referencePattern.ReplaceAllStringFunc(doc, func(block string) string {
+		m := referencePattern.FindStringSubmatch(block)
+		var sb strings.Builder
+		sb.WriteString(m[1])
+		sb.WriteString("| Function | Description | Example | Input | Output |\n")
+		sb.WriteString("|----------|-------------|---------|-------|--------|\n")
+		for _, name := range r.order {
+			spec := r.byName[name].Spec()
+			if spec.Category != m[2] {
+				continue
+			}
+			signature := spec.Name
+			if len(spec.Params) > 0 {
+				var params []string
+				for _, p := range spec.Params {
+					params = append(params, p.Name)
+				}
+				signature += "(" + strings.Join(params, ",") + ")"
+			}
+			example := spec.Example
+			if example == "" {
+				example = "[[VALUE:" + spec.Name + "]]"
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n", referenceCode(signature), strings.ReplaceAll(spec.Description, "|", `\|`),
+				referenceCode(example), referenceCode(spec.Input), referenceCode(spec.Output))
+		}
+		sb.WriteString(m[3])
+		return sb.String()
+	})
+}
+
+// referenceCode formats s as inline code for a Markdown table cell.
+func referenceCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateDocs = flag.Bool("update", false, "rewrite doc/functions.md from the transformation registry")
+
+func TestFunctionsDocUpToDate(t *testing.T) {
+	path := filepath.Join("..", "doc", "functions.md")
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	generated := DefaultTransformRegistry().Reference(string(doc))
+	if *updateDocs {
+		if err := os.WriteFile(path, []byte(generated), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+		return
+	}
+	if generated != string(doc) {
+		t.Errorf("%s is out of date with the transformation registry; run make docs", path)
+	}
+	for _, name := range builtinTransforms.Names() {
+		if !strings.Contains(generated, "| `"+name) {
+			t.Errorf("%s is missing from the reference", name)
+		}
+	}
+}
+
+func TestCustomTransformers(t *testing.T) {
+	jiraKey := NewTransformer(TransformSpec{
+		Name:   "jiraKey",
+		Params: []TransformParam{{Name: "project", Type: StringParam}, {Name: "width", Type: CountParam}},
+	}, func(v string, a TransformArgs) (string, error) {
+		return a.String(0) + "-" + strings.Repeat("0", a.Int(1)-len(v)) + v, nil
+	})
+	r, err := (&FileReplacer{}).WithTransformers(jiraKey)
+	if err != nil {
+		t.Fatalf("WithTransformers failed: %v", err)
+	}
+	fr := r.(*FileReplacer)
+
+	got, _, err := fr.renderText("a.txt", "[[TICKET:jiraKey(OPS,4):toLowerCase]]", &renderScope{values: map[string]string{"TICKET": "42"}}, "[[", "]]")
+	if err != nil || got != "ops-0042" {
+		t.Errorf("expected ops-0042, got %q (%v)", got, err)
+	}
+	got, _, err = fr.renderGoTemplate("a.tpl", `[[ .TICKET | jiraKey "OPS" 3 ]]`, map[string]interface{}{"TICKET": "7"}, "[[", "]]")
+	if err != nil || got != "OPS-007" {
+		t.Errorf("expected OPS-007, got %q (%v)", got, err)
+	}
+	// the replacer it was derived from keeps the built-ins only
+	if _, err := applyChain(&FileReplacer{}, "42", "jiraKey(OPS,4)"); err == nil || !strings.Contains(err.Error(), "unsupported transformation function: jiraKey(OPS,4)") {
+		t.Errorf("expected jiraKey to be unknown, got %v", err)
+	}
+
+	failures := []struct {
+		chain    string
+		expected string
+	}{
+		{"jiraKey(OPS)", "jiraKey expects 2 argument(s), got 1"},
+		{"jiraKey(OPS,x)", "jiraKey(OPS,x): width must be an integer"},
+		{"jiraKey(OPS,-1)", "jiraKey(OPS,-1): width must be at least 0, got -1"},
+	}
+	for _, tt := range failures {
+		if _, err := applyChain(fr, "42", tt.chain); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.chain, tt.expected, err)
+		}
+	}
+
+	if _, err := fr.WithTransformers(jiraKey); err == nil || !strings.Contains(err.Error(), `transformation "jiraKey" is already registered`) {
+		t.Errorf("expected a duplicate error, got %v", err)
+	}
+	bad := NewTransformer(TransformSpec{Name: "to-upper"}, nil)
+	if _, err := fr.WithTransformers(bad); err == nil || !strings.Contains(err.Error(), `invalid transformation name "to-upper"`) {
+		t.Errorf("expected an invalid name error, got %v", err)
+	}
+}